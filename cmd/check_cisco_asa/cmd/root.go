@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var warningThreshold uint32
+var criticalThreshold uint32
+var expectedActive string
+var standalone bool
+
+// CISCO-FIREWALL-MIB, cfwHardwareStatusTable indexed by cfwHardwareType
+const cfwHardwareStatusValueOID string = "1.3.6.1.4.1.9.9.147.1.2.1.1.1.3"
+const cfwHardwareStatusDetailOID string = "1.3.6.1.4.1.9.9.147.1.2.1.1.1.4"
+
+// CISCO-FIREWALL-MIB, cfwConnectionStatTable indexed by cfwConnectionStatService.cfwConnectionStatType
+const cfwConnectionStatValueOID string = "1.3.6.1.4.1.9.9.147.1.2.2.2.1.5"
+const cfwConnectionStatCurrentInUseIndex string = "40.6" // protoIp.currentInUse
+const cfwConnectionStatHighIndex string = "40.7"         // protoIp.high
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_asa",
+	Short: "Cisco ASA failover and connection count check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		if expectedActive != "" && expectedActive != "primary" && expectedActive != "secondary" {
+			slog.Error("--expect-active must be 'primary' or 'secondary'", "value", expectedActive)
+			os.Exit(1)
+		}
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		deviceModelFamily, rawDeviceModel, err := common.GetDeviceModelFromSysObjectID(&conn)
+		if err != nil {
+			slog.Error("Problem when attempting to get the model of the device.", "error", err)
+			os.Exit(1)
+		}
+		if *deviceModelFamily != CiscoModelFamilyASA {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: fmt.Sprintf("Device is not a known ASA (sysObjectID %s)", rawDeviceModel)})
+		}
+		slog.SetDefault(slog.With("model", rawDeviceModel, "modelFamily", deviceModelFamily))
+
+		err = conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// get cfwHardwareStatusValue
+		result, err := common.BulkWalkToMap(&conn, cfwHardwareStatusValueOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cfwHardwareStatusValueOID, "error", err)
+			os.Exit(1)
+		}
+		cfwHardwareStatusValue := make(map[SnmpCfwHardwareType]SnmpCfwResourceStatus)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cfwHardwareStatusValueOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cfwHardwareStatusValue[SnmpCfwHardwareType(it_index)] = SnmpCfwResourceStatus(v)
+		}
+
+		// get cfwHardwareStatusDetail
+		result, err = common.BulkWalkToMap(&conn, cfwHardwareStatusDetailOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cfwHardwareStatusDetailOID, "error", err)
+			os.Exit(1)
+		}
+		cfwHardwareStatusDetail := make(map[SnmpCfwHardwareType]string)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", cfwHardwareStatusDetailOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cfwHardwareStatusDetail[SnmpCfwHardwareType(it_index)] = v
+		}
+
+		// get cfwConnectionStatValue
+		connResult, err := common.BulkWalkToStringMap(&conn, cfwConnectionStatValueOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cfwConnectionStatValueOID, "error", err)
+			os.Exit(1)
+		}
+		currentConnections, haveConnections := connResult[cfwConnectionStatCurrentInUseIndex].(uint32)
+		highConnections, _ := connResult[cfwConnectionStatHighIndex].(uint32)
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var problems []string
+		var longOutput strings.Builder
+		var perfData string
+
+		primary := cfwHardwareStatusValue[CfwHardwareTypePrimaryUnit]
+		secondary := cfwHardwareStatusValue[CfwHardwareTypeSecondaryUnit]
+		primaryString := strings.TrimPrefix(primary.String(), "CfwResourceStatus")
+		secondaryString := strings.TrimPrefix(secondary.String(), "CfwResourceStatus")
+		longOutput.WriteString(fmt.Sprintf("Primary unit: %s (%s)\n", primaryString, cfwHardwareStatusDetail[CfwHardwareTypePrimaryUnit]))
+		longOutput.WriteString(fmt.Sprintf("Secondary unit: %s (%s)\n", secondaryString, cfwHardwareStatusDetail[CfwHardwareTypeSecondaryUnit]))
+
+		activeUnit := ""
+		switch {
+		case primary == CfwResourceStatusActive:
+			activeUnit = "primary"
+		case secondary == CfwResourceStatusActive:
+			activeUnit = "secondary"
+		}
+
+		if standalone {
+			slog.Debug("Standalone firewall, not checking failover")
+		} else if activeUnit == "" {
+			exitStatus = IcingaCRITICAL
+			problems = append(problems, fmt.Sprintf("no active unit (primary %s, secondary %s)", primaryString, secondaryString))
+		} else {
+			var peer SnmpCfwResourceStatus
+			var peerString string
+			if activeUnit == "primary" {
+				peer, peerString = secondary, secondaryString
+			} else {
+				peer, peerString = primary, primaryString
+			}
+			if peer != CfwResourceStatusStandby {
+				exitStatus = IcingaCRITICAL
+				problems = append(problems, fmt.Sprintf("%s unit is active but the peer is %s, not standby", activeUnit, peerString))
+			}
+			if expectedActive != "" && activeUnit != expectedActive {
+				if exitStatus < IcingaWARN {
+					exitStatus = IcingaWARN
+				}
+				problems = append(problems, fmt.Sprintf("%s unit is active, expected %s (failover has occured)", activeUnit, expectedActive))
+			}
+		}
+
+		if haveConnections {
+			connStatus := IcingaOK
+			if criticalThreshold > 0 && currentConnections >= criticalThreshold {
+				connStatus = IcingaCRITICAL
+			} else if warningThreshold > 0 && currentConnections >= warningThreshold {
+				connStatus = IcingaWARN
+			}
+			if connStatus != IcingaOK {
+				problems = append(problems, fmt.Sprintf("%d connections", currentConnections))
+			}
+			if connStatus > exitStatus {
+				exitStatus = connStatus
+			}
+			longOutput.WriteString(fmt.Sprintf("Connections: %d current, %d highest since boot\n", currentConnections, highConnections))
+			perfData = fmt.Sprintf("'connections'=%d;%s;%s;0; ", currentConnections, optionalThreshold(warningThreshold), optionalThreshold(criticalThreshold))
+		} else {
+			slog.Debug("Device didn't return a current connection count", "oid", cfwConnectionStatValueOID+"."+cfwConnectionStatCurrentInUseIndex)
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			if standalone {
+				exitMsg = fmt.Sprintf("%s standalone, %d connections", rawDeviceModel, currentConnections)
+			} else {
+				exitMsg = fmt.Sprintf("%s unit is active, peer is standby, %d connections", activeUnit, currentConnections)
+			}
+		} else {
+			exitMsg = strings.Join(problems, "; ")
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData})
+
+	},
+}
+
+func optionalThreshold(threshold uint32) string {
+	if threshold == 0 {
+		return ""
+	}
+	return fmt.Sprint(threshold)
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().Uint32VarP(&warningThreshold, "warn", "w", 0, "warning threshold for current connections, disabled when 0")
+	rootCmd.PersistentFlags().Uint32VarP(&criticalThreshold, "crit", "c", 0, "critical threshold for current connections, disabled when 0")
+	rootCmd.PersistentFlags().StringVar(&expectedActive, "expect-active", "", "Warn if this unit (primary or secondary) isn't the active one")
+	rootCmd.PersistentFlags().BoolVar(&standalone, "standalone", false, "The firewall isn't part of a failover pair, only check connections")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,471 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var peerFilter []string
+var remoteAsFilter []uint
+var prefixWarningThreshold uint32
+var prefixCriticalThreshold uint32
+
+// BGP4-MIB, indexed by bgpPeerRemoteAddr (IPv4 only)
+const bgpPeerStateOID string = "1.3.6.1.2.1.15.3.1.2"
+const bgpPeerAdminStatusOID string = "1.3.6.1.2.1.15.3.1.3"
+const bgpPeerRemoteAsOID string = "1.3.6.1.2.1.15.3.1.9"
+const bgpPeerFsmEstablishedTimeOID string = "1.3.6.1.2.1.15.3.1.16" // returns gauge32 (seconds)
+
+// CISCO-BGP4-MIB, cbgpPeerAddrFamilyPrefixTable, indexed by bgpPeerRemoteAddr.afi.safi
+const cbgpPeerAcceptedPrefixesOID string = "1.3.6.1.4.1.9.9.187.1.2.4.1.1"
+
+// CISCO-BGP4-MIB, cbgpPeer2Table, indexed by InetAddressType.InetAddress (length prefixed)
+const cbgpPeer2StateOID string = "1.3.6.1.4.1.9.9.187.1.2.5.1.3"
+const cbgpPeer2AdminStatusOID string = "1.3.6.1.4.1.9.9.187.1.2.5.1.4"
+const cbgpPeer2RemoteAsOID string = "1.3.6.1.4.1.9.9.187.1.2.5.1.11"
+const cbgpPeer2FsmEstablishedTimeOID string = "1.3.6.1.4.1.9.9.187.1.2.5.1.19" // returns gauge32 (seconds)
+
+// CISCO-BGP4-MIB, cbgpPeer2AddrFamilyPrefixTable, indexed by InetAddressType.InetAddress.afi.safi
+const cbgpPeer2AcceptedPrefixesOID string = "1.3.6.1.4.1.9.9.187.1.2.8.1.1"
+
+type bgpPeer struct {
+	address          string
+	remoteAs         uint32
+	state            SnmpBgpPeerState
+	adminStatus      SnmpBgpPeerAdminStatus
+	establishedTime  uint32
+	acceptedPrefixes uint32
+	hasPrefixes      bool
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_bgp",
+	Short: "Cisco BGP peer state check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		var wantedPeers []string
+		for _, it := range peerFilter {
+			ip := net.ParseIP(it)
+			if ip == nil {
+				slog.Error("Unable to parse peer address.", "peer", it)
+				os.Exit(1)
+			}
+			wantedPeers = append(wantedPeers, ip.String())
+		}
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		peers := make(map[string]*bgpPeer)
+
+		// The cbgpPeer2Table covers both address families (and VRF peers on some platforms), so
+		// we walk it first and then fill in anything it's missing from the older bgpPeerTable.
+		slog.Debug("Beginning attempt to get peers from cbgpPeer2Table", "oid", cbgpPeer2StateOID)
+		result, err := common.BulkWalkToStringMap(&conn, cbgpPeer2StateOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cbgpPeer2StateOID, "error", err)
+			os.Exit(1)
+		}
+		peer2Index := make(map[string]string) // raw index -> address
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cbgpPeer2StateOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			address, _, err := parseInetAddressIndex(it_index)
+			if err != nil {
+				slog.Warn("Unable to parse peer address from index", "oid", cbgpPeer2StateOID, "key", it_index, "error", err)
+				continue
+			}
+			peer2Index[it_index] = address
+			peers[address] = &bgpPeer{address: address, state: SnmpBgpPeerState(v)}
+		}
+
+		if len(peer2Index) > 0 {
+			result, err = common.BulkWalkToStringMap(&conn, cbgpPeer2AdminStatusOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cbgpPeer2AdminStatusOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", cbgpPeer2AdminStatusOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if address, ok := peer2Index[it_index]; ok {
+					peers[address].adminStatus = SnmpBgpPeerAdminStatus(v)
+				}
+			}
+
+			result, err = common.BulkWalkToStringMap(&conn, cbgpPeer2RemoteAsOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cbgpPeer2RemoteAsOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(uint32)
+				if !ok {
+					slog.Warn("Unable to convert value to uint32", "oid", cbgpPeer2RemoteAsOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if address, ok := peer2Index[it_index]; ok {
+					peers[address].remoteAs = v
+				}
+			}
+
+			result, err = common.BulkWalkToStringMap(&conn, cbgpPeer2FsmEstablishedTimeOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cbgpPeer2FsmEstablishedTimeOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(uint32)
+				if !ok {
+					slog.Warn("Unable to convert value to uint32", "oid", cbgpPeer2FsmEstablishedTimeOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if address, ok := peer2Index[it_index]; ok {
+					peers[address].establishedTime = v
+				}
+			}
+
+			// accepted prefixes are per address family, so we sum them per peer
+			result, err = common.BulkWalkToStringMap(&conn, cbgpPeer2AcceptedPrefixesOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cbgpPeer2AcceptedPrefixesOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(uint32)
+				if !ok {
+					slog.Warn("Unable to convert value to uint32", "oid", cbgpPeer2AcceptedPrefixesOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				address, _, err := parseInetAddressIndex(it_index)
+				if err != nil {
+					slog.Warn("Unable to parse peer address from index", "oid", cbgpPeer2AcceptedPrefixesOID, "key", it_index, "error", err)
+					continue
+				}
+				if peer, ok := peers[address]; ok {
+					peer.acceptedPrefixes += v
+					peer.hasPrefixes = true
+				}
+			}
+		}
+
+		slog.Debug("Beginning attempt to get peers from bgpPeerTable", "oid", bgpPeerStateOID)
+		result, err = common.BulkWalkToStringMap(&conn, bgpPeerStateOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", bgpPeerStateOID, "error", err)
+			os.Exit(1)
+		}
+		var legacyPeers []string
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", bgpPeerStateOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			if _, ok := peers[it_index]; ok {
+				continue
+			}
+			peers[it_index] = &bgpPeer{address: it_index, state: SnmpBgpPeerState(v)}
+			legacyPeers = append(legacyPeers, it_index)
+		}
+
+		if len(legacyPeers) > 0 {
+			result, err = common.BulkWalkToStringMap(&conn, bgpPeerAdminStatusOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", bgpPeerAdminStatusOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", bgpPeerAdminStatusOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if slices.Contains(legacyPeers, it_index) {
+					peers[it_index].adminStatus = SnmpBgpPeerAdminStatus(v)
+				}
+			}
+
+			result, err = common.BulkWalkToStringMap(&conn, bgpPeerRemoteAsOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", bgpPeerRemoteAsOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", bgpPeerRemoteAsOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if slices.Contains(legacyPeers, it_index) {
+					peers[it_index].remoteAs = uint32(v)
+				}
+			}
+
+			result, err = common.BulkWalkToStringMap(&conn, bgpPeerFsmEstablishedTimeOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", bgpPeerFsmEstablishedTimeOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(uint32)
+				if !ok {
+					slog.Warn("Unable to convert value to uint32", "oid", bgpPeerFsmEstablishedTimeOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if slices.Contains(legacyPeers, it_index) {
+					peers[it_index].establishedTime = v
+				}
+			}
+
+			result, err = common.BulkWalkToStringMap(&conn, cbgpPeerAcceptedPrefixesOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cbgpPeerAcceptedPrefixesOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(uint32)
+				if !ok {
+					slog.Warn("Unable to convert value to uint32", "oid", cbgpPeerAcceptedPrefixesOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				// index is a.b.c.d.afi.safi
+				parts := strings.Split(it_index, ".")
+				if len(parts) != 6 {
+					slog.Warn("Unexpected index format", "oid", cbgpPeerAcceptedPrefixesOID, "key", it_index)
+					continue
+				}
+				address := strings.Join(parts[:4], ".")
+				if slices.Contains(legacyPeers, address) {
+					peers[address].acceptedPrefixes += v
+					peers[address].hasPrefixes = true
+				}
+			}
+		}
+
+		// apply the filters
+		var addresses []string
+		for it_index, it := range peers {
+			if len(wantedPeers) > 0 && !slices.Contains(wantedPeers, it_index) {
+				slog.Debug("Peer not in filter, skipping", "peer", it_index)
+				continue
+			}
+			if len(remoteAsFilter) > 0 && !slices.Contains(remoteAsFilter, uint(it.remoteAs)) {
+				slog.Debug("Peer remote AS not in filter, skipping", "peer", it_index, "remoteAs", it.remoteAs)
+				continue
+			}
+			addresses = append(addresses, it_index)
+		}
+		sort.Strings(addresses)
+
+		if len(addresses) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "No BGP peers found (or none matched the filters)."})
+		}
+
+		// every peer we were explicitly asked about should exist
+		var missingPeers []string
+		for _, it := range wantedPeers {
+			if _, ok := peers[it]; !ok {
+				missingPeers = append(missingPeers, it)
+			}
+		}
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var perfData strings.Builder
+		var longOutput strings.Builder
+		var notEstablished []string
+		var lowPrefixes []string
+
+		for _, it := range missingPeers {
+			exitStatus = IcingaCRITICAL
+			longOutput.WriteString(fmt.Sprintf("[%s] %s is not configured on the device\n", IcingaCRITICAL, it))
+		}
+
+		for _, it := range addresses {
+			peer := peers[it]
+			peerStatus := IcingaOK
+			var peerMsg strings.Builder
+
+			peerMsg.WriteString(fmt.Sprintf("%s (AS %d) is %s", peer.address, peer.remoteAs, strings.ToLower(strings.TrimPrefix(peer.state.String(), "BgpPeerState"))))
+
+			switch {
+			case peer.adminStatus == BgpPeerAdminStatusStop:
+				peerMsg.WriteString(" (administratively stopped)")
+			case peer.state != BgpPeerStateEstablished:
+				peerStatus = IcingaCRITICAL
+				notEstablished = append(notEstablished, peer.address)
+			default:
+				peerMsg.WriteString(fmt.Sprintf(", up %s", time.Duration(peer.establishedTime)*time.Second))
+				perfData.WriteString(fmt.Sprintf("'%s_uptime'=%ds;;;0; ", peer.address, peer.establishedTime))
+
+				if peer.hasPrefixes {
+					peerMsg.WriteString(fmt.Sprintf(", %d accepted prefixes", peer.acceptedPrefixes))
+					perfData.WriteString(fmt.Sprintf("'%s_prefixes'=%d;%s;%s;0; ", peer.address, peer.acceptedPrefixes, lowerBound(prefixWarningThreshold), lowerBound(prefixCriticalThreshold)))
+
+					if prefixCriticalThreshold > 0 && peer.acceptedPrefixes < prefixCriticalThreshold {
+						peerStatus = IcingaCRITICAL
+					} else if prefixWarningThreshold > 0 && peer.acceptedPrefixes < prefixWarningThreshold {
+						peerStatus = IcingaWARN
+					}
+					if peerStatus != IcingaOK {
+						lowPrefixes = append(lowPrefixes, peer.address)
+					}
+				}
+			}
+
+			if peerStatus > exitStatus {
+				exitStatus = peerStatus
+			}
+			longOutput.WriteString(fmt.Sprintf("[%s] %s\n", peerStatus, peerMsg.String()))
+		}
+
+		var exitMsg strings.Builder
+		if exitStatus == IcingaOK {
+			exitMsg.WriteString(fmt.Sprintf("All %d BGP peers are established", len(addresses)))
+		} else {
+			if len(missingPeers) > 0 {
+				exitMsg.WriteString(fmt.Sprintf("Peers missing: %s; ", strings.Join(missingPeers, ", ")))
+			}
+			if len(notEstablished) > 0 {
+				exitMsg.WriteString(fmt.Sprintf("Peers not established: %s; ", strings.Join(notEstablished, ", ")))
+			}
+			if len(lowPrefixes) > 0 {
+				exitMsg.WriteString(fmt.Sprintf("Peers below prefix threshold: %s; ", strings.Join(lowPrefixes, ", ")))
+			}
+		}
+
+		// @Speed
+		exitMsgString := strings.TrimSuffix(exitMsg.String(), "; ")
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsgString, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
+	},
+}
+
+// parseInetAddressIndex pulls an InetAddressType.InetAddress pair off the front of an index, where
+// the address is length prefixed (eg. "1.4.10.0.0.1" or "2.16.32.1...") and returns the rest
+func parseInetAddressIndex(index string) (string, []string, error) {
+	parts := strings.Split(index, ".")
+	if len(parts) < 2 {
+		return "", nil, fmt.Errorf("index too short: %s", index)
+	}
+
+	length, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to parse address length: %w", err)
+	}
+	if len(parts) < 2+length {
+		return "", nil, fmt.Errorf("index shorter than address length: %s", index)
+	}
+
+	// ipv4z and ipv6z carry a 4 byte zone index on the end which we don't care about
+	addrLength := length
+	switch parts[0] {
+	case "3", "4":
+		addrLength -= 4
+	}
+	if addrLength != net.IPv4len && addrLength != net.IPv6len {
+		return "", nil, fmt.Errorf("unsupported address length %d in index: %s", addrLength, index)
+	}
+
+	ip := make(net.IP, addrLength)
+	for it_index := 0; it_index < addrLength; it_index++ {
+		octet, err := strconv.Atoi(parts[2+it_index])
+		if err != nil {
+			return "", nil, fmt.Errorf("unable to parse address octet: %w", err)
+		}
+		ip[it_index] = byte(octet)
+	}
+
+	return ip.String(), parts[2+length:], nil
+}
+
+// lowerBound renders a threshold as an icinga range that alerts when below the value
+func lowerBound(threshold uint32) string {
+	if threshold == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:", threshold)
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().StringSliceVar(&peerFilter, "peer", nil, "Only check these peer addresses, alerting if they are missing (can be repeated or comma separated)")
+	rootCmd.PersistentFlags().UintSliceVar(&remoteAsFilter, "remote-as", nil, "Only check peers in these remote AS numbers (can be repeated or comma separated)")
+	rootCmd.PersistentFlags().Uint32VarP(&prefixWarningThreshold, "warn-prefixes", "w", 0, "warning when a peer has fewer accepted prefixes than this (0 to disable)")
+	rootCmd.PersistentFlags().Uint32VarP(&prefixCriticalThreshold, "crit-prefixes", "c", 0, "critical when a peer has fewer accepted prefixes than this (0 to disable)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var unsavedWindow time.Duration
+var recentChangeWindow time.Duration
+
+// @Assumption: the device loads startup-config into running-config shortly
+// after boot, which bumps ccmHistoryRunningLastChanged without anyone having
+// touched it. Changes within --boot-grace of boot are treated as that load.
+var bootConfigLoadGrace time.Duration
+
+const sysUpTimeOID string = "1.3.6.1.2.1.1.3.0" // returns timeticks
+
+// CISCO-CONFIG-MAN-MIB (all scalars, returns timestamp (sysUpTime of the event))
+const ccmHistoryRunningLastChangedOID string = "1.3.6.1.4.1.9.9.43.1.1.1.0"
+const ccmHistoryStartupLastChangedOID string = "1.3.6.1.4.1.9.9.43.1.1.3.0"
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_config_drift",
+	Short: "Cisco running vs startup configuration drift check plugin",
+	Long: `Warns when the running config has been changed and not saved to startup-config.
+
+The device loads startup-config into running-config as it boots, which counts as
+a change. Changes within --boot-grace of boot (default 5m) are taken to be that
+load and ignored, raise it for devices that take longer to come up.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		raw, err := common.SnmpGet(&conn, sysUpTimeOID)
+		if err != nil {
+			slog.Error("Error while attempting to get sysUpTime.", "oid", sysUpTimeOID, "error", err)
+			os.Exit(1)
+		}
+		sysUpTime := raw.(uint32) // @Assumption
+
+		raw, err = common.SnmpGet(&conn, ccmHistoryRunningLastChangedOID)
+		if err != nil {
+			if err.Error() == "SNMP Response: No Such Object" {
+				common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Device does not support CISCO-CONFIG-MAN-MIB"})
+			}
+			slog.Error("Error while attempting to get running config last changed.", "oid", ccmHistoryRunningLastChangedOID, "error", err)
+			os.Exit(1)
+		}
+		runningLastChanged := raw.(uint32) // @Assumption
+
+		raw, err = common.SnmpGet(&conn, ccmHistoryStartupLastChangedOID)
+		if err != nil {
+			slog.Error("Error while attempting to get startup config last changed.", "oid", ccmHistoryStartupLastChangedOID, "error", err)
+			os.Exit(1)
+		}
+		startupLastChanged := raw.(uint32) // @Assumption
+
+		// all three are in hundredths of a second since boot
+		sinceRunningChanged := time.Duration(sysUpTime-runningLastChanged) * 10 * time.Millisecond
+		runningChangedAt := time.Duration(runningLastChanged) * 10 * time.Millisecond
+		slog.Debug("Collected config history", "sysUpTime", sysUpTime, "runningLastChanged", runningLastChanged, "startupLastChanged", startupLastChanged)
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var problems []string
+		var longOutput strings.Builder
+
+		changedSinceBoot := runningChangedAt > bootConfigLoadGrace
+		unsaved := changedSinceBoot && runningLastChanged > startupLastChanged
+
+		if unsaved {
+			longOutput.WriteString(fmt.Sprintf("Running config changed %s ago and has not been saved\n", sinceRunningChanged.Round(time.Second)))
+			if sinceRunningChanged > unsavedWindow {
+				exitStatus = IcingaWARN
+				problems = append(problems, fmt.Sprintf("running config unsaved for %s", sinceRunningChanged.Round(time.Second)))
+			}
+		} else if changedSinceBoot {
+			longOutput.WriteString(fmt.Sprintf("Running config changed %s ago and has been saved\n", sinceRunningChanged.Round(time.Second)))
+		} else {
+			longOutput.WriteString("Running config has not changed since boot\n")
+		}
+
+		if recentChangeWindow > 0 && changedSinceBoot && sinceRunningChanged < recentChangeWindow {
+			exitStatus = IcingaWARN
+			problems = append(problems, fmt.Sprintf("running config changed %s ago", sinceRunningChanged.Round(time.Second)))
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			if unsaved {
+				exitMsg = fmt.Sprintf("Running config unsaved for %s (within %s)", sinceRunningChanged.Round(time.Second), unsavedWindow)
+			} else {
+				exitMsg = "Running config is saved"
+			}
+		} else {
+			exitMsg = strings.Join(problems, "; ")
+		}
+
+		var perfData string
+		if unsaved {
+			perfData = fmt.Sprintf("'unsaved_for'=%.0fs;%.0f;;0; ", sinceRunningChanged.Seconds(), unsavedWindow.Seconds())
+		} else {
+			perfData = fmt.Sprintf("'unsaved_for'=0s;%.0f;;0; ", unsavedWindow.Seconds())
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData})
+
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().DurationVarP(&unsavedWindow, "unsaved-window", "w", time.Hour, "Warn if the running config has been unsaved for longer than this (eg. 30m, 24h)")
+	rootCmd.PersistentFlags().DurationVar(&recentChangeWindow, "recent-change", 0, "Also warn on any config change within this period (eg. 15m), disabled when 0")
+	rootCmd.PersistentFlags().DurationVar(&bootConfigLoadGrace, "boot-grace", 5*time.Minute, "Ignore running config changes this soon after boot, when the device loads its startup config")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
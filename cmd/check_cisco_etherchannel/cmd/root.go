@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var portChannelFilter string
+var minimumMembers uint
+var expectedMembers []string
+
+// IEEE8023-LAG-MIB, dot3adAggTable indexed by the port-channel ifIndex
+const dot3adAggActorAdminKeyOID string = "1.2.840.10006.300.43.1.1.1.1.6"
+
+// IEEE8023-LAG-MIB, dot3adAggPortTable indexed by the member ifIndex
+const dot3adAggPortActorAdminKeyOID string = "1.2.840.10006.300.43.1.2.1.1.4"
+const dot3adAggPortSelectedAggIDOID string = "1.2.840.10006.300.43.1.2.1.1.12"
+const dot3adAggPortAttachedAggIDOID string = "1.2.840.10006.300.43.1.2.1.1.13"
+const dot3adAggPortActorOperStateOID string = "1.2.840.10006.300.43.1.2.1.1.21" // returns LacpState (BITS)
+
+// CISCO-PAGP-MIB, pagpEtherChannelTable indexed by the member ifIndex. IOS fills in
+// pagpGroupIfIndex for every channel member whatever the protocol (PAgP, LACP or on), so it's
+// used for membership instead of CISCO-LAG-MIB clagAggPortListTable, which gives a PortList
+// of dot1dBasePort numbers that would need BRIDGE-MIB to map back to ifIndex, and doesn't say
+// anything about a member that the LAG-MIB columns above don't.
+const pagpGroupIfIndexOID string = "1.3.6.1.4.1.9.9.98.1.1.1.1.8"
+
+const ifOperStatusOID string = "1.3.6.1.2.1.2.2.1.8"
+
+// LacpState bits, BITS are numbered from the most significant bit of the first octet
+const (
+	lacpStateAggregation  byte = 0x20
+	lacpStateCollecting   byte = 0x08
+	lacpStateDistributing byte = 0x04
+	lacpStateDefaulted    byte = 0x02
+)
+
+type channelMember struct {
+	ifIndex   int
+	state     string
+	lacpState byte
+	hasLacp   bool
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_etherchannel",
+	Short: "Cisco EtherChannel / LACP member health check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		var patternForPortChannel *regexp.Regexp
+		if portChannelFilter != "" {
+			var err error
+			patternForPortChannel, err = regexp.Compile(portChannelFilter)
+			if err != nil {
+				slog.Error("Unable to compile port-channel filter.", "pattern", portChannelFilter, "error", err)
+				os.Exit(1)
+			}
+		}
+
+		// --expected-members takes name=count, eg. Po1=2
+		expectedMemberCount := make(map[string]int)
+		for _, it := range expectedMembers {
+			name, countString, found := strings.Cut(it, "=")
+			count, err := strconv.Atoi(countString)
+			if !found || err != nil || count < 0 {
+				slog.Error("Unable to parse expected members, should look like 'Po1=2'", "expected-members", it)
+				os.Exit(1)
+			}
+			expectedMemberCount[strings.ToLower(name)] = count
+		}
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		ifName, err := common.GetIfNames(&conn)
+		if err != nil {
+			slog.Error("Unable to get interface names.", "error", err)
+			os.Exit(1)
+		}
+
+		ifAlias, err := common.GetIfAliases(&conn)
+		if err != nil {
+			slog.Error("Unable to get interface aliases.", "error", err)
+			os.Exit(1)
+		}
+
+		// get ifOperStatus
+		result, err := common.BulkWalkToMap(&conn, ifOperStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", ifOperStatusOID, "error", err)
+			os.Exit(1)
+		}
+		ifOperStatus := make(map[int]SnmpIfOperStatus)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", ifOperStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			ifOperStatus[it_index] = SnmpIfOperStatus(v)
+		}
+
+		// get dot3adAggActorAdminKey, this gives us every port-channel and its key
+		result, err = common.BulkWalkToMap(&conn, dot3adAggActorAdminKeyOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", dot3adAggActorAdminKeyOID, "error", err)
+			os.Exit(1)
+		}
+		aggregatorByKey := make(map[int]int)
+		channels := make(map[int][]*channelMember)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", dot3adAggActorAdminKeyOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			aggregatorByKey[v] = it_index
+			channels[it_index] = nil
+		}
+
+		// get member -> port-channel mappings, attached is the strongest signal so it's applied last
+		memberOf := make(map[int]int)
+
+		result, err = common.BulkWalkToMap(&conn, dot3adAggPortActorAdminKeyOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", dot3adAggPortActorAdminKeyOID, "error", err)
+			os.Exit(1)
+		}
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", dot3adAggPortActorAdminKeyOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			if aggregator, ok := aggregatorByKey[v]; ok && aggregator != it_index {
+				memberOf[it_index] = aggregator
+			}
+		}
+
+		// PAgP (and static) channels are not always in the LAG-MIB
+		result, err = common.BulkWalkToMap(&conn, pagpGroupIfIndexOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", pagpGroupIfIndexOID, "error", err)
+			os.Exit(1)
+		}
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", pagpGroupIfIndexOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			if v != 0 && v != it_index {
+				memberOf[it_index] = v
+			}
+		}
+
+		attached := make(map[int]bool)
+		for _, oid := range []string{dot3adAggPortSelectedAggIDOID, dot3adAggPortAttachedAggIDOID} {
+			result, err = common.BulkWalkToMap(&conn, oid)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", oid, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", oid, "key", it_index, "raw_value", it)
+					continue
+				}
+				if v != 0 && v != it_index {
+					memberOf[it_index] = v
+					if oid == dot3adAggPortAttachedAggIDOID {
+						attached[it_index] = true
+					}
+				}
+			}
+		}
+
+		// get dot3adAggPortActorOperState
+		result, err = common.BulkWalkToMap(&conn, dot3adAggPortActorOperStateOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", dot3adAggPortActorOperStateOID, "error", err)
+			os.Exit(1)
+		}
+		lacpState := make(map[int]byte)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok || len(v) == 0 {
+				slog.Warn("Unable to convert value to string", "oid", dot3adAggPortActorOperStateOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			lacpState[it_index] = v[0]
+		}
+
+		for it_index, it := range memberOf {
+			member := &channelMember{ifIndex: it_index}
+			member.lacpState, member.hasLacp = lacpState[it_index]
+
+			switch {
+			case ifOperStatus[it_index] != IfOperStatusUp:
+				member.state = "down"
+			case member.hasLacp && member.lacpState&lacpStateAggregation == 0,
+				member.hasLacp && member.lacpState&lacpStateDefaulted != 0:
+				member.state = "individual"
+			case member.hasLacp && member.lacpState&(lacpStateCollecting|lacpStateDistributing) != (lacpStateCollecting|lacpStateDistributing):
+				member.state = "suspended"
+			case !member.hasLacp && !attached[it_index] && ifOperStatus[it] == IfOperStatusUp:
+				// PAgP and static members don't report LACP state, but if the channel is up
+				// and this member isn't attached to it, it isn't carrying traffic
+				member.state = "suspended"
+			default:
+				member.state = "bundled"
+			}
+
+			channels[it] = append(channels[it], member)
+		}
+
+		var channelIndexes []int
+		for it_index := range channels {
+			name := ifName[it_index]
+			if patternForPortChannel != nil && !patternForPortChannel.MatchString(name) {
+				slog.Debug("Port-channel does not match filter, skipping", "portChannel", name, "pattern", portChannelFilter)
+				continue
+			}
+			channelIndexes = append(channelIndexes, it_index)
+		}
+		sort.Ints(channelIndexes)
+
+		if len(channelIndexes) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "No port-channels found (or none matched the filter)."})
+		}
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var perfData strings.Builder
+		var longOutput strings.Builder
+		var problems []string
+		seenExpectations := make(map[string]bool)
+
+		for _, it := range channelIndexes {
+			channelStatus := IcingaOK
+			var reasons []string
+			name := ifName[it]
+			if name == "" {
+				name = fmt.Sprintf("ifIndex %d", it)
+			}
+
+			members := channels[it]
+			sort.Slice(members, func(i, j int) bool { return members[i].ifIndex < members[j].ifIndex })
+
+			bundled := 0
+			var memberSummaries []string
+			for _, member := range members {
+				if member.state == "bundled" {
+					bundled += 1
+				} else {
+					channelStatus = IcingaWARN
+					reasons = append(reasons, fmt.Sprintf("%s is %s", ifName[member.ifIndex], member.state))
+				}
+				memberSummaries = append(memberSummaries, fmt.Sprintf("%s (%s)", ifName[member.ifIndex], member.state))
+			}
+
+			expected := int(minimumMembers)
+			if count, ok := expectedMemberCount[strings.ToLower(name)]; ok {
+				expected = count
+				seenExpectations[strings.ToLower(name)] = true
+			}
+
+			switch {
+			case len(members) > 0 && bundled == 0:
+				channelStatus = IcingaCRITICAL
+				reasons = append(reasons, "no members are bundled")
+			case bundled < expected:
+				channelStatus = IcingaWARN
+				reasons = append(reasons, fmt.Sprintf("only %d of %d members are bundled", bundled, expected))
+			}
+
+			var description string
+			if ifAlias[it] != "" {
+				description = fmt.Sprintf(" \"%s\"", ifAlias[it])
+			}
+			longOutput.WriteString(fmt.Sprintf("[%s] %s%s: %d/%d bundled", channelStatus, name, description, bundled, len(members)))
+			if len(memberSummaries) > 0 {
+				longOutput.WriteString(fmt.Sprintf(" - %s", strings.Join(memberSummaries, ", ")))
+			}
+			longOutput.WriteString("\n")
+
+			var warnAt string
+			if expected > 0 {
+				warnAt = fmt.Sprintf("%d:", expected)
+			}
+			perfData.WriteString(fmt.Sprintf("'%s_bundled'=%d;%s;1:;0;%d ", name, bundled, warnAt, len(members)))
+
+			if len(reasons) > 0 {
+				problems = append(problems, fmt.Sprintf("%s %s", name, strings.Join(reasons, ", ")))
+			}
+			if channelStatus > exitStatus {
+				exitStatus = channelStatus
+			}
+		}
+
+		for it := range expectedMemberCount {
+			if !seenExpectations[it] {
+				exitStatus = IcingaCRITICAL
+				problems = append(problems, fmt.Sprintf("%s does not exist", it))
+			}
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("All %d port-channels have their members bundled", len(channelIndexes))
+		} else {
+			sort.Strings(problems)
+			exitMsg = strings.Join(problems, "; ")
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().StringVar(&portChannelFilter, "port-channels", "", "Only check port-channels whose name matches this regex (eg. '^Po1[0-9]$')")
+	rootCmd.PersistentFlags().UintVar(&minimumMembers, "min-members", 0, "Warn when fewer than this many members are bundled in any port-channel (0 to disable)")
+	rootCmd.PersistentFlags().StringSliceVar(&expectedMembers, "expected-members", nil, "Expected bundled member count per port-channel as 'name=count', eg. 'Po1=2' (can be repeated or comma separated)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
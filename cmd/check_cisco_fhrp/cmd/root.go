@@ -0,0 +1,403 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var expectedRoles []string
+
+// CISCO-HSRP-MIB, cHsrpGrpTable indexed by ifIndex.cHsrpGrpNumber
+const cHsrpGrpPriorityOID string = "1.3.6.1.4.1.9.9.106.1.2.1.1.3"
+const cHsrpGrpVirtualIpAddrOID string = "1.3.6.1.4.1.9.9.106.1.2.1.1.11"
+const cHsrpGrpActiveRouterOID string = "1.3.6.1.4.1.9.9.106.1.2.1.1.13"
+const cHsrpGrpStandbyRouterOID string = "1.3.6.1.4.1.9.9.106.1.2.1.1.14"
+const cHsrpGrpStandbyStateOID string = "1.3.6.1.4.1.9.9.106.1.2.1.1.15"
+
+// VRRP-MIB, vrrpOperTable indexed by ifIndex.vrrpOperVrId
+const vrrpOperStateOID string = "1.3.6.1.2.1.68.1.3.1.3"
+const vrrpOperPriorityOID string = "1.3.6.1.2.1.68.1.3.1.5"
+const vrrpOperMasterIpAddrOID string = "1.3.6.1.2.1.68.1.3.1.7"
+
+const unsetIpAddress string = "0.0.0.0"
+
+type fhrpGroup struct {
+	protocol      string
+	ifIndex       int
+	group         string
+	priority      int
+	virtualIp     string
+	activeRouter  string
+	standbyRouter string
+	hsrpState     SnmpHsrpState
+	vrrpState     SnmpVrrpOperState
+}
+
+// role normalises the protocol specific states to active/standby so one --expect works for both
+func (f *fhrpGroup) role() string {
+	switch {
+	case f.protocol == "HSRP" && f.hsrpState == HsrpStateActive,
+		f.protocol == "VRRP" && f.vrrpState == VrrpOperStateMaster:
+		return "active"
+	case f.protocol == "HSRP" && f.hsrpState == HsrpStateStandby,
+		f.protocol == "VRRP" && f.vrrpState == VrrpOperStateBackup:
+		return "standby"
+	default:
+		return ""
+	}
+}
+
+func (f *fhrpGroup) state() string {
+	if f.protocol == "HSRP" {
+		return strings.ToLower(strings.TrimPrefix(f.hsrpState.String(), "HsrpState"))
+	}
+	return strings.ToLower(strings.TrimPrefix(f.vrrpState.String(), "VrrpOperState"))
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_fhrp",
+	Short: "Cisco HSRP / VRRP first-hop redundancy check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		// --expect takes [interface/]group=role, where a bare group number matches that group on any interface
+		expectations := make(map[string]string)
+		for _, it := range expectedRoles {
+			group, role, found := strings.Cut(it, "=")
+			role = strings.ToLower(role)
+			switch role {
+			case "master":
+				role = "active"
+			case "backup":
+				role = "standby"
+			}
+			if !found || group == "" || (role != "active" && role != "standby") {
+				slog.Error("Unable to parse expected role, should look like '[interface/]group=active|standby'", "expect", it)
+				os.Exit(1)
+			}
+			expectations[strings.ToLower(group)] = role
+		}
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// get ifName
+		ifName, err := common.GetIfNames(&conn)
+		if err != nil {
+			slog.Error("Unable to get interface names.", "error", err)
+			os.Exit(1)
+		}
+
+		groups := make(map[string]*fhrpGroup)
+
+		// get cHsrpGrpStandbyState
+		slog.Debug("Beginning attempt to get HSRP groups", "oid", cHsrpGrpStandbyStateOID)
+		result, err := common.BulkWalkToStringMap(&conn, cHsrpGrpStandbyStateOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cHsrpGrpStandbyStateOID, "error", err)
+			os.Exit(1)
+		}
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cHsrpGrpStandbyStateOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			ifIndex, group, err := splitInterfaceIndex(it_index)
+			if err != nil {
+				slog.Warn("Unexpected index format", "oid", cHsrpGrpStandbyStateOID, "key", it_index, "error", err)
+				continue
+			}
+			groups["HSRP."+it_index] = &fhrpGroup{protocol: "HSRP", ifIndex: ifIndex, group: group, hsrpState: SnmpHsrpState(v)}
+		}
+
+		if len(groups) > 0 {
+			// get cHsrpGrpPriority
+			result, err = common.BulkWalkToStringMap(&conn, cHsrpGrpPriorityOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cHsrpGrpPriorityOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(uint32)
+				if !ok {
+					slog.Warn("Unable to convert value to uint32", "oid", cHsrpGrpPriorityOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if group, ok := groups["HSRP."+it_index]; ok {
+					group.priority = int(v)
+				}
+			}
+
+			// get cHsrpGrpVirtualIpAddr, cHsrpGrpActiveRouter and cHsrpGrpStandbyRouter
+			for _, oid := range []string{cHsrpGrpVirtualIpAddrOID, cHsrpGrpActiveRouterOID, cHsrpGrpStandbyRouterOID} {
+				result, err = common.BulkWalkToStringMap(&conn, oid)
+				if err != nil {
+					slog.Error("BulkWalk of device failed.", "oid", oid, "error", err)
+					os.Exit(1)
+				}
+				for it_index, it := range result {
+					v, ok := it.(string)
+					if !ok {
+						slog.Warn("Unable to convert value to string", "oid", oid, "key", it_index, "raw_value", it)
+						continue
+					}
+					group, ok := groups["HSRP."+it_index]
+					if !ok {
+						continue
+					}
+					switch oid {
+					case cHsrpGrpVirtualIpAddrOID:
+						group.virtualIp = v
+					case cHsrpGrpActiveRouterOID:
+						group.activeRouter = v
+					case cHsrpGrpStandbyRouterOID:
+						group.standbyRouter = v
+					}
+				}
+			}
+		}
+
+		// get vrrpOperState
+		slog.Debug("Beginning attempt to get VRRP groups", "oid", vrrpOperStateOID)
+		result, err = common.BulkWalkToStringMap(&conn, vrrpOperStateOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", vrrpOperStateOID, "error", err)
+			os.Exit(1)
+		}
+		numberOfVrrpGroups := 0
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", vrrpOperStateOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			ifIndex, group, err := splitInterfaceIndex(it_index)
+			if err != nil {
+				slog.Warn("Unexpected index format", "oid", vrrpOperStateOID, "key", it_index, "error", err)
+				continue
+			}
+			groups["VRRP."+it_index] = &fhrpGroup{protocol: "VRRP", ifIndex: ifIndex, group: group, vrrpState: SnmpVrrpOperState(v)}
+			numberOfVrrpGroups += 1
+		}
+
+		if numberOfVrrpGroups > 0 {
+			// get vrrpOperPriority
+			result, err = common.BulkWalkToStringMap(&conn, vrrpOperPriorityOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", vrrpOperPriorityOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", vrrpOperPriorityOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if group, ok := groups["VRRP."+it_index]; ok {
+					group.priority = v
+				}
+			}
+
+			// get vrrpOperMasterIpAddr
+			result, err = common.BulkWalkToStringMap(&conn, vrrpOperMasterIpAddrOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", vrrpOperMasterIpAddrOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(string)
+				if !ok {
+					slog.Warn("Unable to convert value to string", "oid", vrrpOperMasterIpAddrOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if group, ok := groups["VRRP."+it_index]; ok {
+					group.activeRouter = v
+				}
+			}
+		}
+
+		if len(groups) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "No HSRP or VRRP groups found."})
+		}
+
+		var keys []string
+		for it_index := range groups {
+			keys = append(keys, it_index)
+		}
+		sort.Strings(keys)
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var longOutput strings.Builder
+		var problems []string
+		matchedExpectations := make(map[string]bool)
+
+		for _, it := range keys {
+			group := groups[it]
+			groupStatus := IcingaOK
+			var reasons []string
+
+			interfaceName := ifName[group.ifIndex]
+			if interfaceName == "" {
+				interfaceName = fmt.Sprintf("ifIndex %d", group.ifIndex)
+			}
+			name := fmt.Sprintf("%s %s group %s", interfaceName, group.protocol, group.group)
+
+			switch {
+			case group.protocol == "HSRP" && (group.hsrpState == HsrpStateInitial || group.hsrpState == HsrpStateLearn || group.hsrpState == HsrpStateListen || group.hsrpState == HsrpStateSpeak):
+				groupStatus = IcingaWARN
+				reasons = append(reasons, fmt.Sprintf("is in %s state", group.state()))
+			case group.protocol == "VRRP" && group.vrrpState == VrrpOperStateInitialize:
+				groupStatus = IcingaWARN
+				reasons = append(reasons, fmt.Sprintf("is in %s state", group.state()))
+			}
+
+			if group.protocol == "HSRP" && group.hsrpState == HsrpStateActive && (group.standbyRouter == "" || group.standbyRouter == unsetIpAddress) {
+				groupStatus = IcingaWARN
+				reasons = append(reasons, "has no standby router")
+			}
+
+			// the group satisfies both an interface/group and a bare group expectation, but when
+			// both are given the more specific one decides the expected role
+			var expectedRole string
+			for _, key := range []string{group.group, strings.ToLower(interfaceName + "/" + group.group)} {
+				if role, ok := expectations[key]; ok {
+					matchedExpectations[key] = true
+					expectedRole = role
+				}
+			}
+			if expectedRole != "" {
+				if group.role() != expectedRole {
+					groupStatus = IcingaWARN
+					reasons = append(reasons, fmt.Sprintf("should be %s", expectedRole))
+				}
+			}
+
+			var detail strings.Builder
+			detail.WriteString(fmt.Sprintf("%s is %s (priority %d", name, group.state(), group.priority))
+			if group.virtualIp != "" {
+				detail.WriteString(fmt.Sprintf(", vip %s", group.virtualIp))
+			}
+			if group.activeRouter != "" && group.activeRouter != unsetIpAddress {
+				detail.WriteString(fmt.Sprintf(", active %s", group.activeRouter))
+			}
+			if group.standbyRouter != "" && group.standbyRouter != unsetIpAddress {
+				detail.WriteString(fmt.Sprintf(", standby %s", group.standbyRouter))
+			}
+			detail.WriteString(")")
+			if len(reasons) > 0 {
+				detail.WriteString(" - ")
+				detail.WriteString(strings.Join(reasons, ", "))
+				problems = append(problems, fmt.Sprintf("%s %s", name, strings.Join(reasons, ", ")))
+			}
+
+			longOutput.WriteString(fmt.Sprintf("[%s] %s\n", groupStatus, detail.String()))
+
+			if groupStatus > exitStatus {
+				exitStatus = groupStatus
+			}
+		}
+
+		for it := range expectations {
+			if !matchedExpectations[it] {
+				exitStatus = IcingaWARN
+				problems = append(problems, fmt.Sprintf("group %s is not configured", it))
+				longOutput.WriteString(fmt.Sprintf("[%s] group %s was expected but is not configured\n", IcingaWARN, it))
+			}
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("%d HSRP and %d VRRP groups are in their expected state", len(groups)-numberOfVrrpGroups, numberOfVrrpGroups)
+		} else {
+			sort.Strings(problems)
+			exitMsg = strings.Join(problems, "; ")
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String()})
+
+	},
+}
+
+// splitInterfaceIndex splits an ifIndex.group table index
+func splitInterfaceIndex(index string) (int, string, error) {
+	ifIndexPart, group, found := strings.Cut(index, ".")
+	if !found {
+		return 0, "", fmt.Errorf("index has no group part: %s", index)
+	}
+	ifIndex, err := strconv.Atoi(ifIndexPart)
+	if err != nil {
+		return 0, "", fmt.Errorf("unable to parse ifIndex: %w", err)
+	}
+	return ifIndex, group, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().StringSliceVar(&expectedRoles, "expect", nil, "Expected role of a group on this device as '[interface/]group=active|standby', eg. 'Vl10/10=active' (can be repeated or comma separated)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var warningThreshold uint32
+var criticalThreshold uint32
+var warningFreeBytes uint64
+var criticalFreeBytes uint64
+var filesystemFilter string
+
+// CISCO-FLASH-MIB, ciscoFlashDeviceTable indexed by ciscoFlashDeviceIndex
+const ciscoFlashDeviceNameOID string = "1.3.6.1.4.1.9.9.10.1.1.2.1.7"
+
+// CISCO-FLASH-MIB, ciscoFlashPartitionTable indexed by ciscoFlashDeviceIndex.ciscoFlashPartitionIndex
+const ciscoFlashPartitionSizeOID string = "1.3.6.1.4.1.9.9.10.1.1.4.1.1.4"
+const ciscoFlashPartitionFreeSpaceOID string = "1.3.6.1.4.1.9.9.10.1.1.4.1.1.5"
+const ciscoFlashPartitionNameOID string = "1.3.6.1.4.1.9.9.10.1.1.4.1.1.10"
+const ciscoFlashPartitionSizeExtendedOID string = "1.3.6.1.4.1.9.9.10.1.1.4.1.1.13"
+const ciscoFlashPartitionFreeSpaceExtendedOID string = "1.3.6.1.4.1.9.9.10.1.1.4.1.1.14"
+
+// HOST-RESOURCES-MIB, hrStorageTable indexed by hrStorageIndex
+const hrStorageTypeOID string = "1.3.6.1.2.1.25.2.3.1.2"
+const hrStorageDescrOID string = "1.3.6.1.2.1.25.2.3.1.3"
+const hrStorageAllocationUnitsOID string = "1.3.6.1.2.1.25.2.3.1.4"
+const hrStorageSizeOID string = "1.3.6.1.2.1.25.2.3.1.5"
+const hrStorageUsedOID string = "1.3.6.1.2.1.25.2.3.1.6"
+
+const hrStorageFixedDiskOID string = "1.3.6.1.2.1.25.2.1.4"
+const hrStorageFlashMemoryOID string = "1.3.6.1.2.1.25.2.1.9"
+
+type filesystem struct {
+	name string
+	size uint64
+	free uint64
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_flash",
+	Short: "Cisco flash / filesystem free space check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		patternForFilesystem, err := regexp.Compile(filesystemFilter)
+		if err != nil {
+			slog.Error("Unable to compile filesystem pattern.", "pattern", filesystemFilter, "error", err)
+			os.Exit(1)
+		}
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err = conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// stacks expose a flash device per member (flash-1, flash-2, ...), so this covers every member
+		filesystems := getFlashPartitions()
+		if len(filesystems) == 0 {
+			slog.Debug("Device returned no flash partitions, falling back to hrStorageTable")
+			filesystems = getHrStorage()
+		}
+		if len(filesystems) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Device did not return any filesystems from CISCO-FLASH-MIB or HOST-RESOURCES-MIB."})
+		}
+		sort.Slice(filesystems, func(i, j int) bool { return filesystems[i].name < filesystems[j].name })
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var longOutput strings.Builder
+		var perfData strings.Builder
+		var problems []string
+		checked := 0
+
+		for _, it := range filesystems {
+			if !patternForFilesystem.MatchString(it.name) {
+				slog.Debug("Skipping filesystem that doesn't match pattern", "filesystem", it.name)
+				continue
+			}
+			if it.size == 0 {
+				slog.Debug("Skipping empty filesystem (no media inserted?)", "filesystem", it.name)
+				continue
+			}
+			checked += 1
+
+			used := it.size - it.free
+			usedPercent := math.Round(float64(used)/float64(it.size)*1000) / 10
+
+			fsStatus := IcingaOK
+			if usedPercent >= float64(criticalThreshold) || (criticalFreeBytes > 0 && it.free < criticalFreeBytes) {
+				fsStatus = IcingaCRITICAL
+			} else if usedPercent >= float64(warningThreshold) || (warningFreeBytes > 0 && it.free < warningFreeBytes) {
+				fsStatus = IcingaWARN
+			}
+			if fsStatus > exitStatus {
+				exitStatus = fsStatus
+			}
+			if fsStatus != IcingaOK {
+				problems = append(problems, fmt.Sprintf("%s %v%% used (%s free)", it.name, usedPercent, humanBytes(it.free)))
+			}
+
+			longOutput.WriteString(fmt.Sprintf("[%s] %s: %v%% used, %s free of %s\n", fsStatus, it.name, usedPercent, humanBytes(it.free), humanBytes(it.size)))
+
+			warnAt := math.Round(float64(it.size) * (float64(warningThreshold) / 100))
+			critAt := math.Round(float64(it.size) * (float64(criticalThreshold) / 100))
+			perfData.WriteString(fmt.Sprintf("'%s_used'=%dB;%v;%v;0;%d ", it.name, used, warnAt, critAt, it.size))
+			perfData.WriteString(fmt.Sprintf("'%s_free'=%dB;%s;%s;0;%d ", it.name, it.free, lowerBound(warningFreeBytes), lowerBound(criticalFreeBytes), it.size))
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("All %d filesystems are within thresholds", checked)
+		} else {
+			exitMsg = strings.Join(problems, "; ")
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
+	},
+}
+
+func getFlashPartitions() []filesystem {
+	// get ciscoFlashDeviceName
+	result, err := common.BulkWalkToMap(&conn, ciscoFlashDeviceNameOID)
+	if err != nil {
+		slog.Error("BulkWalk of device failed.", "oid", ciscoFlashDeviceNameOID, "error", err)
+		os.Exit(1)
+	}
+	ciscoFlashDeviceName := make(map[string]string)
+	for it_index, it := range result {
+		v, ok := it.(string)
+		if !ok {
+			slog.Warn("Unable to convert value to string", "oid", ciscoFlashDeviceNameOID, "key", it_index, "raw_value", it)
+			continue
+		}
+		ciscoFlashDeviceName[fmt.Sprint(it_index)] = v
+	}
+
+	// get ciscoFlashPartitionName
+	partitionResult, err := common.BulkWalkToStringMap(&conn, ciscoFlashPartitionNameOID)
+	if err != nil {
+		slog.Error("BulkWalk of device failed.", "oid", ciscoFlashPartitionNameOID, "error", err)
+		os.Exit(1)
+	}
+	ciscoFlashPartitionName := make(map[string]string)
+	for it_index, it := range partitionResult {
+		v, ok := it.(string)
+		if !ok {
+			slog.Warn("Unable to convert value to string", "oid", ciscoFlashPartitionNameOID, "key", it_index, "raw_value", it)
+			continue
+		}
+		ciscoFlashPartitionName[it_index] = v
+	}
+
+	size := walkPartitionBytes(ciscoFlashPartitionSizeOID, ciscoFlashPartitionSizeExtendedOID)
+	free := walkPartitionBytes(ciscoFlashPartitionFreeSpaceOID, ciscoFlashPartitionFreeSpaceExtendedOID)
+
+	var returnSlice []filesystem
+	for it_index, it := range size {
+		name := ciscoFlashPartitionName[it_index]
+		if name == "" {
+			deviceIndex, _, _ := strings.Cut(it_index, ".")
+			name = ciscoFlashDeviceName[deviceIndex]
+		}
+		if name == "" {
+			name = it_index
+		}
+		// without the free space the partition would look full, so leave it out
+		freeBytes, ok := free[it_index]
+		if !ok {
+			slog.Warn("Partition has no free space reported, skipping", "partition", name, "oid", ciscoFlashPartitionFreeSpaceOID, "key", it_index)
+			continue
+		}
+		returnSlice = append(returnSlice, filesystem{name: name, size: it, free: freeBytes})
+	}
+
+	return returnSlice
+}
+
+// walkPartitionBytes prefers the 64 bit column, since the 32 bit one tops out at 4GB
+// (and some platforms report 0 there once the partition is bigger than that).
+func walkPartitionBytes(oid32 string, oid64 string) map[string]uint64 {
+	returnMap := make(map[string]uint64)
+
+	result, err := common.BulkWalkToStringMap(&conn, oid32)
+	if err != nil {
+		slog.Error("BulkWalk of device failed.", "oid", oid32, "error", err)
+		os.Exit(1)
+	}
+	for it_index, it := range result {
+		v, ok := it.(uint32)
+		if !ok {
+			slog.Warn("Unable to convert value to uint32", "oid", oid32, "key", it_index, "raw_value", it)
+			continue
+		}
+		returnMap[it_index] = uint64(v)
+	}
+
+	result, err = common.BulkWalkToStringMap(&conn, oid64)
+	if err != nil {
+		slog.Debug("Unable to walk 64 bit column, continuing with 32 bit values", "oid", oid64, "error", err)
+		return returnMap
+	}
+	for it_index, it := range result {
+		v, ok := it.(uint64)
+		if !ok {
+			slog.Warn("Unable to convert value to uint64", "oid", oid64, "key", it_index, "raw_value", it)
+			continue
+		}
+		if v > 0 {
+			returnMap[it_index] = v
+		}
+	}
+
+	return returnMap
+}
+
+func getHrStorage() []filesystem {
+	// get hrStorageType
+	result, err := common.BulkWalkToMap(&conn, hrStorageTypeOID)
+	if err != nil {
+		slog.Error("BulkWalk of device failed.", "oid", hrStorageTypeOID, "error", err)
+		os.Exit(1)
+	}
+	var storageIndexes []int
+	for it_index, it := range result {
+		v, ok := it.(string)
+		if !ok {
+			slog.Warn("Unable to convert value to string", "oid", hrStorageTypeOID, "key", it_index, "raw_value", it)
+			continue
+		}
+		v = strings.TrimPrefix(v, ".")
+		if v == hrStorageFixedDiskOID || v == hrStorageFlashMemoryOID {
+			storageIndexes = append(storageIndexes, it_index)
+		}
+	}
+	if len(storageIndexes) == 0 {
+		return nil
+	}
+
+	columns := make(map[string]map[int]int)
+	for _, oid := range []string{hrStorageAllocationUnitsOID, hrStorageSizeOID, hrStorageUsedOID} {
+		result, err := common.BulkWalkToMap(&conn, oid)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", oid, "error", err)
+			os.Exit(1)
+		}
+		columns[oid] = make(map[int]int)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", oid, "key", it_index, "raw_value", it)
+				continue
+			}
+			columns[oid][it_index] = v
+		}
+	}
+
+	result, err = common.BulkWalkToMap(&conn, hrStorageDescrOID)
+	if err != nil {
+		slog.Error("BulkWalk of device failed.", "oid", hrStorageDescrOID, "error", err)
+		os.Exit(1)
+	}
+
+	var returnSlice []filesystem
+	for _, it := range storageIndexes {
+		name, _ := result[it].(string)
+		if name == "" {
+			name = fmt.Sprintf("hrStorage%d", it)
+		}
+		units := uint64(columns[hrStorageAllocationUnitsOID][it])
+		size := uint64(columns[hrStorageSizeOID][it]) * units
+		used := uint64(columns[hrStorageUsedOID][it]) * units
+		if used > size {
+			used = size
+		}
+		returnSlice = append(returnSlice, filesystem{name: name, size: size, free: size - used})
+	}
+
+	return returnSlice
+}
+
+func lowerBound(threshold uint64) string {
+	if threshold == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:", threshold)
+}
+
+func humanBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().Uint32VarP(&warningThreshold, "warn", "w", 80, "warning threshold for filesystem utilisation (in percent)")
+	rootCmd.PersistentFlags().Uint32VarP(&criticalThreshold, "crit", "c", 90, "critical threshold for filesystem utilisation (in percent)")
+	rootCmd.PersistentFlags().Uint64Var(&warningFreeBytes, "warn-free", 0, "warn if a filesystem has fewer free bytes than this, disabled when 0")
+	rootCmd.PersistentFlags().Uint64Var(&criticalFreeBytes, "crit-free", 0, "critical if a filesystem has fewer free bytes than this, disabled when 0")
+	rootCmd.PersistentFlags().StringVar(&filesystemFilter, "filesystems", "", "Only check filesystems whose name matches this regex (eg. '^(boot)?flash')")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var baselineFile string
+var writeBaseline bool
+
+type inventoryItem struct {
+	Class       string `json:"class"`
+	Name        string `json:"name"`
+	Model       string `json:"model"`
+	Serial      string `json:"serial"`
+	HardwareRev string `json:"hardware_rev"`
+	FirmwareRev string `json:"firmware_rev"`
+}
+
+type inventoryBaseline struct {
+	Host    string          `json:"host"`
+	Created time.Time       `json:"created"`
+	Items   []inventoryItem `json:"items"`
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_inventory",
+	Short: "Cisco hardware inventory drift check plugin",
+	Long: `Compares the hardware inventory (ENTITY-MIB) against a baseline file.
+
+Run once with --write-baseline to record the current inventory, then without
+it to report additions, removals and serial number changes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		entries, err := common.GetEntPhysicalEntries(&conn)
+		if err != nil {
+			slog.Error("Problem when attempting to get the entity table of the device.", "error", err)
+			os.Exit(1)
+		}
+		current := inventoryFromEntries(entries)
+		slog.Debug("Collected inventory", "items", len(current))
+
+		if writeBaseline {
+			baseline := inventoryBaseline{Host: conn.Target, Created: time.Now().UTC(), Items: current}
+
+			data, err := json.MarshalIndent(baseline, "", "  ")
+			if err != nil {
+				slog.Error("Unable to encode baseline.", "error", err)
+				os.Exit(1)
+			}
+			if err := os.WriteFile(baselineFile, append(data, '\n'), 0644); err != nil {
+				slog.Error("Unable to write baseline file.", "file", baselineFile, "error", err)
+				os.Exit(1)
+			}
+			common.ExitPlugin(&IcingaStatus{Value: IcingaOK, Message: fmt.Sprintf("Wrote %d inventory items to %s", len(current), baselineFile)})
+		}
+
+		data, err := os.ReadFile(baselineFile)
+		if err != nil {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: fmt.Sprintf("Unable to read baseline (run with --write-baseline first?): %v", err)})
+		}
+		var baseline inventoryBaseline
+		if err := json.Unmarshal(data, &baseline); err != nil {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: fmt.Sprintf("Unable to parse baseline %s: %v", baselineFile, err)})
+		}
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var longOutput strings.Builder
+
+		diff := compareInventory(baseline.Items, current)
+		for _, it := range diff.removed {
+			longOutput.WriteString(fmt.Sprintf("[%s] Removed: %s (%s, serial %s)\n", IcingaWARN, it.Name, it.Model, it.Serial))
+		}
+		for _, it := range diff.replaced {
+			longOutput.WriteString(fmt.Sprintf("[%s] Replaced: %s serial %s -> %s (%s)\n", IcingaWARN, it[1].Name, it[0].Serial, it[1].Serial, it[1].Model))
+		}
+		for _, it := range diff.added {
+			longOutput.WriteString(fmt.Sprintf("[%s] Added: %s (%s, serial %s)\n", IcingaWARN, it.Name, it.Model, it.Serial))
+		}
+		added, removed, changed := len(diff.added), len(diff.removed), len(diff.replaced)
+
+		var exitMsg string
+		if added+removed+changed == 0 {
+			exitMsg = fmt.Sprintf("Inventory matches baseline from %s (%d items)", baseline.Created.Format("2006-01-02"), len(current))
+		} else {
+			exitStatus = IcingaWARN
+			exitMsg = fmt.Sprintf("Inventory differs from baseline from %s: %d added, %d removed, %d replaced", baseline.Created.Format("2006-01-02"), added, removed, changed)
+		}
+		perfData := fmt.Sprintf("'added'=%d;;;0; 'removed'=%d;;;0; 'replaced'=%d;;;0;", added, removed, changed)
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData})
+
+	},
+}
+
+// inventoryFromEntries keeps the field replaceable parts of the entity table, in
+// entPhysicalIndex order. They're matched up by name and serial rather than by index, since
+// the indexes aren't stable across reloads.
+func inventoryFromEntries(entries map[int]EntPhysicalEntry) []inventoryItem {
+	var indexes []int
+	for it_index := range entries {
+		indexes = append(indexes, it_index)
+	}
+	sort.Ints(indexes)
+
+	var returnSlice []inventoryItem
+	for _, it := range indexes {
+		entry := entries[it]
+
+		// ports, sensors, containers etc. have neither, and aren't something that gets swapped
+		if entry.SerialNum == "" && entry.ModelName == "" {
+			continue
+		}
+
+		name := entry.Name
+		if name == "" {
+			name = entry.Descr
+		}
+
+		returnSlice = append(returnSlice, inventoryItem{
+			Class:       strings.TrimPrefix(entry.Class.String(), "IanaPhysicalClass"),
+			Name:        name,
+			Model:       entry.ModelName,
+			Serial:      entry.SerialNum,
+			HardwareRev: entry.HardwareRev,
+			FirmwareRev: entry.FirmwareRev,
+		})
+	}
+
+	return returnSlice
+}
+
+type inventoryDiff struct {
+	added    []inventoryItem
+	removed  []inventoryItem
+	replaced [][2]inventoryItem // was, now
+}
+
+// compareInventory matches up parts with the same name. Names aren't unique (eg. every PSU in
+// a stack can be called "Power Supply"), so parts are first matched on their serial, and those
+// without one by model and the order they come in. Whatever is left over with the same name is
+// taken to be replaced, in order, and the rest has been added or removed.
+func compareInventory(expected []inventoryItem, current []inventoryItem) inventoryDiff {
+	byName := func(items []inventoryItem) map[string][]inventoryItem {
+		returnMap := make(map[string][]inventoryItem)
+		for _, it := range items {
+			returnMap[it.Name] = append(returnMap[it.Name], it)
+		}
+		return returnMap
+	}
+	expectedByName := byName(expected)
+	currentByName := byName(current)
+
+	names := make(map[string]bool)
+	for it := range expectedByName {
+		names[it] = true
+	}
+	for it := range currentByName {
+		names[it] = true
+	}
+	var sortedNames []string
+	for it := range names {
+		sortedNames = append(sortedNames, it)
+	}
+	sort.Strings(sortedNames)
+
+	var diff inventoryDiff
+	for _, name := range sortedNames {
+		was := expectedByName[name]
+		now := currentByName[name]
+
+		// drop the parts found in both, by serial or, without one, by model and order
+		matched := make(map[int]bool) // index into now
+		var wasLeft []inventoryItem
+		for _, it := range was {
+			found := -1
+			for now_index, candidate := range now {
+				if matched[now_index] {
+					continue
+				}
+				if it.Serial != "" && candidate.Serial == it.Serial {
+					found = now_index
+					break
+				}
+				if it.Serial == "" && candidate.Serial == "" && candidate.Model == it.Model && found < 0 {
+					found = now_index
+				}
+			}
+			if found >= 0 {
+				matched[found] = true
+				continue
+			}
+			wasLeft = append(wasLeft, it)
+		}
+		var nowLeft []inventoryItem
+		for now_index, it := range now {
+			if !matched[now_index] {
+				nowLeft = append(nowLeft, it)
+			}
+		}
+
+		for len(wasLeft) > 0 && len(nowLeft) > 0 {
+			diff.replaced = append(diff.replaced, [2]inventoryItem{wasLeft[0], nowLeft[0]})
+			wasLeft, nowLeft = wasLeft[1:], nowLeft[1:]
+		}
+		diff.removed = append(diff.removed, wasLeft...)
+		diff.added = append(diff.added, nowLeft...)
+	}
+
+	return diff
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().StringVarP(&baselineFile, "baseline", "b", "", "Path to the inventory baseline file (required)")
+	rootCmd.MarkPersistentFlagRequired("baseline")
+	rootCmd.PersistentFlags().BoolVar(&writeBaseline, "write-baseline", false, "Export the current inventory to the baseline file instead of checking against it")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
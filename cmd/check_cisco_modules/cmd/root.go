@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var stateMapFlag StateMapValue
+
+const entPhysicalNameOID string = "1.3.6.1.2.1.47.1.1.1.1.7"
+
+// CISCO-ENTITY-FRU-CONTROL-MIB, cefcModuleTable indexed by entPhysicalIndex
+const cefcModuleAdminStatusOID string = "1.3.6.1.4.1.9.9.117.1.2.1.1.1"
+const cefcModuleOperStatusOID string = "1.3.6.1.4.1.9.9.117.1.2.1.1.2"
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_modules",
+	Short: "Cisco line card / module status check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		operStateMap := NewStateMap(&stateMapFlag, "ModuleOperType", moduleOperStatusToIcinga)
+		if unmatched := stateMapFlag.Unmatched(); len(unmatched) > 0 {
+			slog.Error("--state-map contains states this check doesn't know about", "states", unmatched)
+			os.Exit(1)
+		}
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// get cefcModuleOperStatus
+		result, err := common.BulkWalkToMap(&conn, cefcModuleOperStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cefcModuleOperStatusOID, "error", err)
+			os.Exit(1)
+		}
+		cefcModuleOperStatus := make(map[int]SnmpModuleOperType)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cefcModuleOperStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cefcModuleOperStatus[it_index] = SnmpModuleOperType(v)
+		}
+
+		if len(cefcModuleOperStatus) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Device did not return any modules from cefcModuleTable."})
+		}
+
+		// get cefcModuleAdminStatus
+		result, err = common.BulkWalkToMap(&conn, cefcModuleAdminStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cefcModuleAdminStatusOID, "error", err)
+			os.Exit(1)
+		}
+		cefcModuleAdminStatus := make(map[int]SnmpModuleAdminType)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cefcModuleAdminStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cefcModuleAdminStatus[it_index] = SnmpModuleAdminType(v)
+		}
+
+		// get entPhysicalName
+		result, err = common.BulkWalkToMap(&conn, entPhysicalNameOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entPhysicalNameOID, "error", err)
+			os.Exit(1)
+		}
+		entPhysicalName := make(map[int]string)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", entPhysicalNameOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entPhysicalName[it_index] = v
+		}
+
+		var modules []int
+		for it_index := range cefcModuleOperStatus {
+			modules = append(modules, it_index)
+		}
+		sort.Ints(modules)
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var longOutput strings.Builder
+		var problems []string
+		numberOfModulesOk := 0
+
+		for _, it := range modules {
+			name := entPhysicalName[it]
+			if name == "" {
+				name = fmt.Sprintf("entPhysicalIndex %d", it)
+			}
+			operStatus := cefcModuleOperStatus[it]
+			adminStatus := cefcModuleAdminStatus[it]
+			operStatusString := strings.TrimPrefix(operStatus.String(), "ModuleOperType")
+
+			// modules that have been shut down on purpose aren't a problem
+			moduleStatus := operStateMap.Status(operStatus)
+			if adminStatus == ModuleAdminTypeDisabled || adminStatus == ModuleAdminTypeOutOfServiceAdmin {
+				moduleStatus = IcingaOK
+				operStatusString += fmt.Sprintf(" (admin %s)", strings.TrimPrefix(adminStatus.String(), "ModuleAdminType"))
+			}
+
+			if moduleStatus == IcingaOK {
+				numberOfModulesOk += 1
+			} else {
+				problems = append(problems, fmt.Sprintf("%s is %s", name, operStatusString))
+			}
+			if moduleStatus > exitStatus {
+				exitStatus = moduleStatus
+			}
+
+			longOutput.WriteString(fmt.Sprintf("[%s] %s: %s\n", moduleStatus, name, operStatusString))
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("All %d modules are ok", len(modules))
+		} else {
+			exitMsg = strings.Join(problems, "; ")
+		}
+		perfData := fmt.Sprintf("'modules_ok'=%d;;;0;%d", numberOfModulesOk, len(modules))
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData})
+
+	},
+}
+
+func moduleOperStatusToIcinga(status SnmpModuleOperType) IcingaStatusVal {
+	switch status {
+	case ModuleOperTypeOk, ModuleOperTypeFwDownloadSuccess:
+		return IcingaOK
+	case ModuleOperTypeFailed,
+		ModuleOperTypeMissing,
+		ModuleOperTypeDiagFailed,
+		ModuleOperTypeMismatchWithParent,
+		ModuleOperTypeMismatchConfig,
+		ModuleOperTypeOutOfServiceEnvTemp,
+		ModuleOperTypePowerDenied,
+		ModuleOperTypePowerCycled,
+		ModuleOperTypeOkButPowerOverCritical,
+		ModuleOperTypeFwDownloadFailure:
+		return IcingaCRITICAL
+	default:
+		// transitional states (boot, selfTest, upgrading, ...) and degraded-but-running ones
+		return IcingaWARN
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().Var(&stateMapFlag, "state-map", "Override the Icinga state of module states, eg. Boot=ok,Missing=warn")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,306 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var warningOffset float64
+var criticalOffset float64
+var maxStratum int
+var allowedPeers []string
+
+// CISCO-NTP-MIB (scalars)
+const cntpSysStratumOID string = "1.3.6.1.4.1.9.9.168.1.1.2.0"
+const cntpSysRootDelayOID string = "1.3.6.1.4.1.9.9.168.1.1.4.0" // returns NTPSignedTimeValue
+const cntpSysPeerOID string = "1.3.6.1.4.1.9.9.168.1.1.9.0"      // returns cntpPeersAssocId of the sync peer
+const cntpSysSrvStatusOID string = "1.3.6.1.4.1.9.9.168.1.1.11.0"
+
+// CISCO-NTP-MIB, cntpPeersVarTable indexed by cntpPeersAssocId
+const cntpPeersPeerAddressOID string = "1.3.6.1.4.1.9.9.168.1.2.1.1.3"
+const cntpPeersStratumOID string = "1.3.6.1.4.1.9.9.168.1.2.1.1.9"
+const cntpPeersOffsetOID string = "1.3.6.1.4.1.9.9.168.1.2.1.1.23" // returns NTPSignedTimeValue
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_ntp",
+	Short: "Cisco NTP synchronisation check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		raw, err := common.SnmpGet(&conn, cntpSysSrvStatusOID)
+		if err != nil {
+			if err.Error() == "SNMP Response: No Such Object" {
+				common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Device does not support CISCO-NTP-MIB"})
+			}
+			slog.Error("Error while attempting to get NTP server status.", "oid", cntpSysSrvStatusOID, "error", err)
+			os.Exit(1)
+		}
+		srvStatus := SnmpNtpSrvStatus(raw.(int)) // @Assumption
+
+		raw, err = common.SnmpGet(&conn, cntpSysStratumOID)
+		if err != nil {
+			slog.Error("Error while attempting to get NTP stratum.", "oid", cntpSysStratumOID, "error", err)
+			os.Exit(1)
+		}
+		stratum := raw.(int) // @Assumption
+
+		var rootDelay float64
+		raw, err = common.SnmpGet(&conn, cntpSysRootDelayOID)
+		if err != nil {
+			slog.Debug("Unable to get NTP root delay, continuing without it", "oid", cntpSysRootDelayOID, "error", err)
+		} else if v, ok := raw.(string); ok {
+			rootDelay = ntpSignedTimeValueToMillis([]byte(v))
+		}
+
+		// cntpSysPeer is a Gauge32, but some devices send it as an Integer. Either way it's keyed
+		// the same as the peer tables below, whose index is the cntpPeersAssocId.
+		sysPeer := 0
+		raw, err = common.SnmpGet(&conn, cntpSysPeerOID)
+		if err != nil {
+			slog.Debug("Unable to get NTP sync peer, continuing without it", "oid", cntpSysPeerOID, "error", err)
+		} else {
+			switch v := raw.(type) {
+			case int:
+				sysPeer = v
+			case uint32:
+				sysPeer = int(v)
+			default:
+				slog.Warn("Unable to convert value to int", "oid", cntpSysPeerOID, "raw_value", raw)
+			}
+		}
+
+		// get cntpPeersPeerAddress
+		result, err := common.BulkWalkToMap(&conn, cntpPeersPeerAddressOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cntpPeersPeerAddressOID, "error", err)
+			os.Exit(1)
+		}
+		cntpPeersPeerAddress := make(map[int]string)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", cntpPeersPeerAddressOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cntpPeersPeerAddress[it_index] = v
+		}
+
+		// get cntpPeersStratum
+		result, err = common.BulkWalkToMap(&conn, cntpPeersStratumOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cntpPeersStratumOID, "error", err)
+			os.Exit(1)
+		}
+		cntpPeersStratum := make(map[int]int)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cntpPeersStratumOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cntpPeersStratum[it_index] = v
+		}
+
+		// get cntpPeersOffset
+		result, err = common.BulkWalkToMap(&conn, cntpPeersOffsetOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cntpPeersOffsetOID, "error", err)
+			os.Exit(1)
+		}
+		cntpPeersOffset := make(map[int]float64)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", cntpPeersOffsetOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cntpPeersOffset[it_index] = ntpSignedTimeValueToMillis([]byte(v))
+		}
+		slog.Debug("Collected NTP status", "srvStatus", srvStatus, "stratum", stratum, "sysPeer", sysPeer, "peers", len(cntpPeersPeerAddress))
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var problems []string
+		var longOutput strings.Builder
+		var perfData strings.Builder
+
+		srvStatusString := strings.TrimPrefix(srvStatus.String(), "NtpSrvStatus")
+		switch srvStatus {
+		case NtpSrvStatusSyncToRemoteServer, NtpSrvStatusSyncToRefclock:
+		case NtpSrvStatusSyncToLocal:
+			exitStatus = IcingaWARN
+			problems = append(problems, "synchronised to the local clock")
+		case NtpSrvStatusNotRunning, NtpSrvStatusNotSynchronized:
+			exitStatus = IcingaCRITICAL
+			problems = append(problems, srvStatusString)
+		default:
+			exitStatus = IcingaUNKNOWN
+			problems = append(problems, fmt.Sprintf("NTP status is %s", srvStatusString))
+		}
+
+		if srvStatus == NtpSrvStatusSyncToRemoteServer || srvStatus == NtpSrvStatusSyncToRefclock {
+			if stratum > maxStratum {
+				if exitStatus < IcingaWARN {
+					exitStatus = IcingaWARN
+				}
+				problems = append(problems, fmt.Sprintf("stratum %d is above %d", stratum, maxStratum))
+			}
+		}
+
+		var peerAddress string
+		var offset float64
+		if srvStatus == NtpSrvStatusSyncToRemoteServer {
+			var ok bool
+			peerAddress, ok = cntpPeersPeerAddress[sysPeer]
+			if !ok {
+				if exitStatus < IcingaWARN {
+					exitStatus = IcingaWARN
+				}
+				problems = append(problems, fmt.Sprintf("sync peer (association %d) not found in peer table", sysPeer))
+			} else {
+				offset = cntpPeersOffset[sysPeer]
+
+				if len(allowedPeers) > 0 && !slices.Contains(allowedPeers, peerAddress) {
+					if exitStatus < IcingaWARN {
+						exitStatus = IcingaWARN
+					}
+					problems = append(problems, fmt.Sprintf("synchronised to %s which is not an allowed peer", peerAddress))
+				}
+
+				offsetStatus := IcingaOK
+				if math.Abs(offset) >= criticalOffset {
+					offsetStatus = IcingaCRITICAL
+				} else if math.Abs(offset) >= warningOffset {
+					offsetStatus = IcingaWARN
+				}
+				if offsetStatus != IcingaOK {
+					problems = append(problems, fmt.Sprintf("offset %.3fms", offset))
+				}
+				if offsetStatus > exitStatus {
+					exitStatus = offsetStatus
+				}
+
+				perfData.WriteString(fmt.Sprintf("'offset'=%.3fms;%v;%v;; ", offset, icingaSymmetricRange(warningOffset), icingaSymmetricRange(criticalOffset)))
+			}
+		}
+		perfData.WriteString(fmt.Sprintf("'stratum'=%d;%d;;0;16 ", stratum, maxStratum))
+		perfData.WriteString(fmt.Sprintf("'root_delay'=%.3fms;;;0; ", rootDelay))
+
+		var peers []int
+		for it_index := range cntpPeersPeerAddress {
+			peers = append(peers, it_index)
+		}
+		sort.Ints(peers)
+		for _, it := range peers {
+			marker := " "
+			if it == sysPeer {
+				marker = "*"
+			}
+			longOutput.WriteString(fmt.Sprintf("%s %s stratum %d offset %.3fms\n", marker, cntpPeersPeerAddress[it], cntpPeersStratum[it], cntpPeersOffset[it]))
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("Synchronised to %s at stratum %d, offset %.3fms", peerAddress, stratum, offset)
+			if srvStatus == NtpSrvStatusSyncToRefclock {
+				exitMsg = fmt.Sprintf("Synchronised to a reference clock at stratum %d", stratum)
+			}
+		} else {
+			exitMsg = fmt.Sprintf("NTP %s", strings.Join(problems, "; "))
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
+	},
+}
+
+// ntpSignedTimeValueToMillis decodes a CISCO-NTP-MIB NTPSignedTimeValue, a
+// signed 16.16 fixed point number of seconds.
+func ntpSignedTimeValueToMillis(b []byte) float64 {
+	if len(b) != 4 {
+		slog.Warn("Unexpected NTPSignedTimeValue length", "raw_value", b)
+		return 0
+	}
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536 * 1000
+}
+
+func icingaSymmetricRange(threshold float64) string {
+	return fmt.Sprintf("%v:%v", -threshold, threshold)
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().Float64VarP(&warningOffset, "warn", "w", 500, "warning threshold for the offset from the sync peer (in milliseconds, either direction)")
+	rootCmd.PersistentFlags().Float64VarP(&criticalOffset, "crit", "c", 1000, "critical threshold for the offset from the sync peer (in milliseconds, either direction)")
+	rootCmd.PersistentFlags().IntVar(&maxStratum, "max-stratum", 5, "Warn if the device's stratum is higher than this")
+	rootCmd.PersistentFlags().StringSliceVar(&allowedPeers, "allowed-peers", nil, "Comma separated list of peer addresses the device may synchronise to (default any)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
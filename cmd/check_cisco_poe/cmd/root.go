@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var warningThreshold uint32
+var criticalThreshold uint32
+
+// POWER-ETHERNET-MIB
+// pethMainPseTable is indexed by pethMainPseGroupIndex, which is the stack member (or module) number.
+// pethPsePortTable is indexed by pethPsePortGroupIndex.pethPsePortIndex
+const pethMainPsePowerOID string = "1.3.6.1.2.1.105.1.3.1.1.2"            // returns gauge32 (watts)
+const pethMainPseOperStatusOID string = "1.3.6.1.2.1.105.1.3.1.1.3"       // returns integer
+const pethMainPseConsumptionPowerOID string = "1.3.6.1.2.1.105.1.3.1.1.4" // returns gauge32 (watts)
+const pethPsePortDetectionStatusOID string = "1.3.6.1.2.1.105.1.1.1.6"    // returns integer
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_poe",
+	Short: "Cisco PoE budget and port fault check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// get pethMainPsePower (available budget per member)
+		result, err := common.BulkWalkToMap(&conn, pethMainPsePowerOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", pethMainPsePowerOID, "error", err)
+			os.Exit(1)
+		}
+		pethMainPsePower := make(map[int]uint32)
+		for it_index, it := range result {
+			v, ok := it.(uint32)
+			if !ok {
+				slog.Warn("Unable to convert value to uint32", "oid", pethMainPsePowerOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			pethMainPsePower[it_index] = v
+		}
+
+		if len(pethMainPsePower) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Device did not return any PoE power sourcing equipment (is this a PoE switch?)"})
+		}
+
+		// get pethMainPseConsumptionPower (used budget per member)
+		result, err = common.BulkWalkToMap(&conn, pethMainPseConsumptionPowerOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", pethMainPseConsumptionPowerOID, "error", err)
+			os.Exit(1)
+		}
+		pethMainPseConsumptionPower := make(map[int]uint32)
+		for it_index, it := range result {
+			v, ok := it.(uint32)
+			if !ok {
+				slog.Warn("Unable to convert value to uint32", "oid", pethMainPseConsumptionPowerOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			pethMainPseConsumptionPower[it_index] = v
+		}
+
+		// get pethMainPseOperStatus
+		result, err = common.BulkWalkToMap(&conn, pethMainPseOperStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", pethMainPseOperStatusOID, "error", err)
+			os.Exit(1)
+		}
+		pethMainPseOperStatus := make(map[int]SnmpPethMainPseOperStatus)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", pethMainPseOperStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			pethMainPseOperStatus[it_index] = SnmpPethMainPseOperStatus(v)
+		}
+
+		// get pethPsePortDetectionStatus (index is group.port, so we need the string map)
+		result2, err := common.BulkWalkToStringMap(&conn, pethPsePortDetectionStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", pethPsePortDetectionStatusOID, "error", err)
+			os.Exit(1)
+		}
+		pethPsePortDetectionStatus := make(map[string]SnmpPethPsePortDetectionStatus)
+		for it_index, it := range result2 {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", pethPsePortDetectionStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			pethPsePortDetectionStatus[it_index] = SnmpPethPsePortDetectionStatus(v)
+		}
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var perfData strings.Builder
+		var exitMsg strings.Builder
+		var longOutput strings.Builder
+
+		var members []int
+		for it_index := range pethMainPsePower {
+			members = append(members, it_index)
+		}
+		sort.Ints(members)
+
+		for _, it := range members {
+			budget := pethMainPsePower[it]
+			used := pethMainPseConsumptionPower[it]
+
+			var usedPercent float64
+			if budget != 0 { // prevent divide by 0
+				usedPercent = math.Round((float64(used) / float64(budget)) * 100)
+			}
+			warnAt := math.Round(float64(budget) * (float64(warningThreshold) / 100))
+			critAt := math.Round(float64(budget) * (float64(criticalThreshold) / 100))
+
+			perfData.WriteString(fmt.Sprintf("'poe_used_%v'=%vW;%v;%v;0;%v ", it, used, warnAt, critAt, budget))
+			perfData.WriteString(fmt.Sprintf("'poe_used_pct_%v'=%v%%;%v;%v;0;100 ", it, usedPercent, warningThreshold, criticalThreshold))
+			exitMsg.WriteString(fmt.Sprintf("PoE (%v): %vW/%vW (%v%%), ", it, used, budget, usedPercent))
+
+			if operStatus, ok := pethMainPseOperStatus[it]; ok && operStatus != PethMainPseOperStatusOn {
+				longOutput.WriteString(fmt.Sprintf("Member %v PSE is %s\n", it, strings.TrimPrefix(operStatus.String(), "PethMainPseOperStatus")))
+				if operStatus == PethMainPseOperStatusFaulty {
+					exitStatus = IcingaCRITICAL
+				} else if exitStatus != IcingaCRITICAL {
+					exitStatus = IcingaWARN
+				}
+			}
+
+			// check thresholds
+			if usedPercent >= float64(criticalThreshold) {
+				exitStatus = IcingaCRITICAL
+			}
+
+			if exitStatus != IcingaCRITICAL && usedPercent >= float64(warningThreshold) {
+				exitStatus = IcingaWARN
+			}
+		}
+
+		var faultyPorts []string
+		for it_index, it := range pethPsePortDetectionStatus {
+			switch it {
+			case PethPsePortDetectionStatusFault, PethPsePortDetectionStatusOtherFault, PethPsePortDetectionStatusTest:
+				faultyPorts = append(faultyPorts, it_index)
+			}
+		}
+		sort.Strings(faultyPorts)
+
+		for _, it := range faultyPorts {
+			group, port, _ := strings.Cut(it, ".")
+			longOutput.WriteString(fmt.Sprintf("Member %s port %s is %s\n", group, port, strings.TrimPrefix(pethPsePortDetectionStatus[it].String(), "PethPsePortDetectionStatus")))
+		}
+
+		if len(faultyPorts) > 0 {
+			if exitStatus != IcingaCRITICAL {
+				exitStatus = IcingaWARN
+			}
+			exitMsg.WriteString(fmt.Sprintf("%d PoE ports are faulty, ", len(faultyPorts)))
+		}
+
+		// @Speed
+		exitMsgString := strings.TrimSuffix(exitMsg.String(), ", ")
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsgString, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().Uint32VarP(&warningThreshold, "warn", "w", 80, "warning threshold for PoE budget utilisation (in percent)")
+	rootCmd.PersistentFlags().Uint32VarP(&criticalThreshold, "crit", "c", 90, "critical threshold for PoE budget utilisation (in percent)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
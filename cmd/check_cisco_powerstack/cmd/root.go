@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,9 +28,23 @@ var maxTimesToRetryModelQuery uint8
 
 var doubleContainers bool
 var psuBuiltIn bool
-
-const cswSwitchStateOID string = "1.3.6.1.4.1.9.9.500.1.2.1.1.6"
-const cswStackPowerPortLinkStatusOID string = "1.3.6.1.4.1.9.9.500.1.3.2.1.5"
+var expectedMode string
+var warningRemaining uint32
+var criticalRemaining uint32
+var stateMapFlag StateMapValue
+
+const cswSwitchNumCurrentOID string = "CISCO-STACKWISE-MIB::cswSwitchNumCurrent"
+const cswSwitchStateOID string = "CISCO-STACKWISE-MIB::cswSwitchState"
+const cswSwitchPowerBudgetOID string = "CISCO-STACKWISE-MIB::cswSwitchPowerBudget"     // returns watts
+const cswSwitchPowerCommitedOID string = "CISCO-STACKWISE-MIB::cswSwitchPowerCommited" // returns watts
+const cswStackPowerPortLinkStatusOID string = "CISCO-STACKWISE-MIB::cswStackPowerPortLinkStatus"
+const cswStackPowerPortNeighborSwitchNumOID string = "CISCO-STACKWISE-MIB::cswStackPowerPortNeighborSwitchNum"
+
+// CISCO-STACKWISE-MIB, cswStackPowerInfoTable indexed by cswStackPowerStackNumber
+const cswStackPowerModeOID string = "CISCO-STACKWISE-MIB::cswStackPowerMode"
+const cswStackPowerMasterSwitchNumOID string = "CISCO-STACKWISE-MIB::cswStackPowerMasterSwitchNum"
+const cswStackPowerNumMembersOID string = "CISCO-STACKWISE-MIB::cswStackPowerNumMembers"
+const cswStackPowerNameOID string = "CISCO-STACKWISE-MIB::cswStackPowerName"
 
 var rootCmd = &cobra.Command{
 	Use:   "check_cisco_powerstack",
@@ -37,6 +53,20 @@ var rootCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		slog.SetDefault(slog.With("target", conn.Target))
 
+		switch expectedMode {
+		case "", "power-sharing", "redundant", "power-sharing-strict", "redundant-strict":
+		default:
+			slog.Error("--expected-mode must be one of power-sharing, redundant, power-sharing-strict or redundant-strict", "value", expectedMode)
+			os.Exit(1)
+		}
+
+		switchStateMap := NewStateMap(&stateMapFlag, "SnmpCswSwitchState", defaultSwitchStateStatus)
+		powerPortStateMap := NewStateMap(&stateMapFlag, "SnmpCswStackPowerPortLinkStatus", defaultPowerPortStatus)
+		if unmatched := stateMapFlag.Unmatched(); len(unmatched) > 0 {
+			slog.Error("--state-map contains states this check doesn't know about", "states", unmatched)
+			os.Exit(1)
+		}
+
 		conn.Version = g.Version3
 		conn.SecurityModel = g.UserSecurityModel
 		conn.MsgFlags = seclevel.Value
@@ -124,31 +154,211 @@ var rootCmd = &cobra.Command{
 			cswStackPowerPortLinkStatus[k] = SnmpCswStackPowerPortLinkStatus(v)
 		}
 
+		// get cswStackPowerMode
+		result, err = common.BulkWalkToMap(&conn, cswStackPowerModeOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cswStackPowerModeOID, "error", err)
+			os.Exit(1)
+		}
+		cswStackPowerMode := make(map[int]SnmpCswStackPowerMode)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cswStackPowerModeOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cswStackPowerMode[it_index] = SnmpCswStackPowerMode(v)
+		}
+
+		// get cswStackPowerName
+		result, err = common.BulkWalkToMap(&conn, cswStackPowerNameOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cswStackPowerNameOID, "error", err)
+			os.Exit(1)
+		}
+		cswStackPowerName := make(map[int]string)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", cswStackPowerNameOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cswStackPowerName[it_index] = v
+		}
+
+		// get cswStackPowerMasterSwitchNum, cswStackPowerNumMembers, cswSwitchNumCurrent,
+		// cswSwitchPowerBudget and cswSwitchPowerCommited, all Unsigned32
+		unsignedColumns := make(map[string]map[int]uint32)
+		for _, oid := range []string{cswStackPowerMasterSwitchNumOID, cswStackPowerNumMembersOID, cswSwitchNumCurrentOID, cswSwitchPowerBudgetOID, cswSwitchPowerCommitedOID} {
+			result, err = common.BulkWalkToMap(&conn, oid)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", oid, "error", err)
+				os.Exit(1)
+			}
+			unsignedColumns[oid] = make(map[int]uint32)
+			for it_index, it := range result {
+				v, ok := it.(uint32)
+				if !ok {
+					slog.Warn("Unable to convert value to uint32", "oid", oid, "key", it_index, "raw_value", it)
+					continue
+				}
+				unsignedColumns[oid][it_index] = v
+			}
+		}
+
+		// get cswStackPowerPortNeighborSwitchNum, indexed by entPhysicalIndex.cswStackPowerPortIndex
+		result2, err = common.BulkWalkToStringMap(&conn, cswStackPowerPortNeighborSwitchNumOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cswStackPowerPortNeighborSwitchNumOID, "error", err)
+			os.Exit(1)
+		}
+		cswStackPowerPortNeighborSwitchNum := make(map[string]uint32)
+		for it_index, it := range result2 {
+			v, ok := it.(uint32)
+			if !ok {
+				slog.Warn("Unable to convert value to uint32", "oid", cswStackPowerPortNeighborSwitchNumOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cswStackPowerPortNeighborSwitchNum[it_index] = v
+		}
+
 		// Assume everything is ok, then check this assumption (See Exit below is changing this code)
 		var exitStatus IcingaStatusVal = IcingaOK
 		var switchStates []SnmpCswSwitchState
 		var stackPowerPortStatuses []SnmpCswStackPowerPortLinkStatus
+		nominal := true // every switch Ready and power port Up, which --state-map can make OK without being true
 
 		for _, v := range cswSwitchState {
-			if v != SnmpCswSwitchStateReady && exitStatus != IcingaWARN {
-				exitStatus = IcingaWARN
+			if status := switchStateMap.Status(v); status > exitStatus {
+				exitStatus = status
+			}
+			if v != SnmpCswSwitchStateReady {
+				nominal = false
 			}
 			switchStates = append(switchStates, v)
 		}
 
 		for _, v := range cswStackPowerPortLinkStatus {
-			if v != SnmpCswStackPowerPortLinkStatusUp && exitStatus != IcingaWARN {
-				exitStatus = IcingaWARN
+			if status := powerPortStateMap.Status(v); status > exitStatus {
+				exitStatus = status
+			}
+			if v != SnmpCswStackPowerPortLinkStatusUp {
+				nominal = false
 			}
 			stackPowerPortStatuses = append(stackPowerPortStatuses, v)
 		}
 
+		// power stacks
+		var problems []string
+		var longOutput strings.Builder
+		var perfData strings.Builder
+
+		var powerStacks []int
+		for it_index := range cswStackPowerMode {
+			powerStacks = append(powerStacks, it_index)
+		}
+		sort.Ints(powerStacks)
+
+		// The power budget is only reported per switch, so it's summed over the members of each
+		// power stack. The MIB doesn't say which power stack a switch is in, so the members are
+		// found by following the power ports that are up from the power stack's master.
+		switchNumber := unsignedColumns[cswSwitchNumCurrentOID]
+		powerLinks := make(map[uint32][]uint32)
+		for it_index, it := range cswStackPowerPortNeighborSwitchNum {
+			if it == 0 || cswStackPowerPortLinkStatus[it_index] != SnmpCswStackPowerPortLinkStatusUp {
+				continue
+			}
+			entPart, _, _ := strings.Cut(it_index, ".")
+			local, err := strconv.Atoi(entPart)
+			if err != nil {
+				slog.Warn("Unable to parse the entPhysicalIndex of a stack power port", "oid", cswStackPowerPortNeighborSwitchNumOID, "key", it_index)
+				continue
+			}
+			if n, ok := switchNumber[local]; ok {
+				powerLinks[n] = append(powerLinks[n], it)
+				powerLinks[it] = append(powerLinks[it], n)
+			}
+		}
+
+		for _, it := range powerStacks {
+			name := cswStackPowerName[it]
+			if name == "" {
+				name = fmt.Sprintf("Powerstack-%d", it)
+			}
+			mode := cswStackPowerMode[it]
+			members := unsignedColumns[cswStackPowerNumMembersOID][it]
+
+			stackStatus := IcingaOK
+			if expectedMode != "" && stackPowerModeFlagName(mode) != expectedMode {
+				stackStatus = IcingaWARN
+				problems = append(problems, fmt.Sprintf("%s is in %s mode, expected %s", name, stackPowerModeFlagName(mode), expectedMode))
+			}
+
+			// the thresholds apply to each power stack, as power can't be shared between them
+			memberSwitches := powerStackMembers(unsignedColumns[cswStackPowerMasterSwitchNumOID][it], powerLinks)
+			if len(powerStacks) == 1 && len(memberSwitches) < len(switchNumber) && int(members) == len(switchNumber) {
+				// a single power stack with every switch in it, even if the power port neighbours aren't reported
+				memberSwitches = make(map[uint32]bool)
+				for _, n := range switchNumber {
+					memberSwitches[n] = true
+				}
+			}
+			if len(memberSwitches) != int(members) {
+				slog.Warn("Couldn't match the switches in a power stack, not checking its remaining power", "powerStack", name, "members", members, "matched", len(memberSwitches))
+			}
+
+			var budget, committed uint32
+			for entPhysicalIndex, n := range switchNumber {
+				if memberSwitches[n] {
+					budget += unsignedColumns[cswSwitchPowerBudgetOID][entPhysicalIndex]
+					committed += unsignedColumns[cswSwitchPowerCommitedOID][entPhysicalIndex]
+				}
+			}
+			var remaining uint32
+			if budget > committed {
+				remaining = budget - committed
+			}
+			hasRemaining := len(memberSwitches) == int(members) && budget > 0
+			if hasRemaining {
+				remainingStatus := IcingaOK
+				if criticalRemaining > 0 && remaining < criticalRemaining {
+					remainingStatus = IcingaCRITICAL
+				} else if warningRemaining > 0 && remaining < warningRemaining {
+					remainingStatus = IcingaWARN
+				}
+				if remainingStatus != IcingaOK {
+					problems = append(problems, fmt.Sprintf("%s has only %dW of %dW power budget remaining", name, remaining, budget))
+				}
+				if remainingStatus > stackStatus {
+					stackStatus = remainingStatus
+				}
+			}
+			if stackStatus > exitStatus {
+				exitStatus = stackStatus
+			}
+
+			longOutput.WriteString(fmt.Sprintf("[%s] %s: %s mode, %d members, master switch %d", stackStatus, name, stackPowerModeFlagName(mode), members, unsignedColumns[cswStackPowerMasterSwitchNumOID][it]))
+			if hasRemaining {
+				longOutput.WriteString(fmt.Sprintf(", budget %dW, allocated %dW, remaining %dW", budget, committed, remaining))
+			}
+			longOutput.WriteString("\n")
+			perfData.WriteString(fmt.Sprintf("'%s_members'=%d;;;0; ", name, members))
+			if hasRemaining {
+				perfData.WriteString(fmt.Sprintf("'%s_budget'=%dW;;;0; ", name, budget))
+				perfData.WriteString(fmt.Sprintf("'%s_allocated'=%dW;;;0;%d ", name, committed, budget))
+				perfData.WriteString(fmt.Sprintf("'%s_remaining'=%dW;%s;%s;0;%d ", name, remaining, lowerBound(warningRemaining), lowerBound(criticalRemaining), budget))
+			}
+		}
+
 		// Exit
 		var exitMsg strings.Builder
+		if len(problems) > 0 {
+			exitMsg.WriteString(strings.Join(problems, "; "))
+			exitMsg.WriteString("; ")
+		}
 
-		// @NOTE: this is not exhaustive, it has an @ASSUMPTION that this plug only ever exits WARN or OK
-		switch exitStatus {
-		case IcingaWARN:
+		switch {
+		default:
 
 			exitMsg.WriteString("Switch states are \"")
 			for it_index, it := range switchStates {
@@ -163,20 +373,77 @@ var rootCmd = &cobra.Command{
 				if it_index > 0 {
 					exitMsg.WriteString(", ")
 				}
-				exitMsg.WriteString(strings.TrimPrefix(it.String(), "SnmpCswStackPortOperState")) // @Speed
+				exitMsg.WriteString(strings.TrimPrefix(it.String(), "SnmpCswStackPowerPortLinkStatus")) // @Speed
 			}
 
 			exitMsg.WriteString("\"")
 
-		case IcingaOK:
+		case exitStatus == IcingaOK && nominal:
 			exitMsg.WriteString(fmt.Sprintf("%d switches are \"ready\" and %d power stack ports are up", len(switchStates), len(stackPowerPortStatuses)))
 		}
 
-		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg.String()})
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg.String(), LongOutput: longOutput.String(), PerfData: perfData.String()})
 
 	},
 }
 
+// defaultSwitchStateStatus is used for switch states not given with --state-map
+func defaultSwitchStateStatus(state SnmpCswSwitchState) IcingaStatusVal {
+	if state == SnmpCswSwitchStateReady {
+		return IcingaOK
+	}
+	return IcingaWARN
+}
+
+// defaultPowerPortStatus is used for stack power port states not given with --state-map
+func defaultPowerPortStatus(state SnmpCswStackPowerPortLinkStatus) IcingaStatusVal {
+	if state == SnmpCswStackPowerPortLinkStatusUp {
+		return IcingaOK
+	}
+	return IcingaWARN
+}
+
+// stackPowerModeFlagName gives the mode as it's written for --expected-mode
+func stackPowerModeFlagName(mode SnmpCswStackPowerMode) string {
+	switch mode {
+	case SnmpCswStackPowerModePowerSharing:
+		return "power-sharing"
+	case SnmpCswStackPowerModeRedundant:
+		return "redundant"
+	case SnmpCswStackPowerModePowerSharingStrict:
+		return "power-sharing-strict"
+	case SnmpCswStackPowerModeRedundantStrict:
+		return "redundant-strict"
+	default:
+		return strings.TrimPrefix(mode.String(), "SnmpCswStackPowerMode")
+	}
+}
+
+// powerStackMembers gives the switch numbers reachable from the power stack's master over
+// stack power links
+func powerStackMembers(master uint32, links map[uint32][]uint32) map[uint32]bool {
+	members := map[uint32]bool{master: true}
+	queue := []uint32{master}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+		for _, neighbour := range links[it] {
+			if !members[neighbour] {
+				members[neighbour] = true
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+	return members
+}
+
+func lowerBound(threshold uint32) string {
+	if threshold == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:", threshold)
+}
+
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&Debug, "debug", "d", false, "Print debug information")
 
@@ -199,6 +466,10 @@ func init() {
 
 	// check specific flags
 	rootCmd.PersistentFlags().Uint8Var(&maxTimesToRetryModelQuery, "max-model-query-retries", 2, "How many times to retry the initial query for model (at half second intervals)")
+	rootCmd.PersistentFlags().Var(&stateMapFlag, "state-map", "Override the Icinga state of switch or stack power port states, eg. Removed=critical,Provisioned=ok (default anything not Ready or Up is warn)")
+	rootCmd.PersistentFlags().StringVar(&expectedMode, "expected-mode", "", "Warn if a power stack isn't in this mode (power-sharing, redundant, power-sharing-strict or redundant-strict)")
+	rootCmd.PersistentFlags().Uint32VarP(&warningRemaining, "warn-remaining", "w", 0, "warning threshold for the remaining power of each power stack (in watts), disabled when 0")
+	rootCmd.PersistentFlags().Uint32VarP(&criticalRemaining, "crit-remaining", "c", 0, "critical threshold for the remaining power of each power stack (in watts), disabled when 0")
 }
 
 func Execute() {
@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
@@ -25,11 +29,27 @@ var authmode SnmpV3AuthProtocolValue
 var privmode SnmpV3PrivProtocolValue
 var psuExpectedOverrideValue uint8
 var maxTimesToRetryModelQuery uint8
+var stateMapFlag StateMapValue
 
 const entPhysicalDescrOID string = "1.3.6.1.2.1.47.1.1.1.1.2"
 const entPhysicalClassOID string = "1.3.6.1.2.1.47.1.1.1.1.5"
+
+// CISCO-ENVMON-MIB, ciscoEnvMonSupplyStatusTable indexed by ciscoEnvMonSupplyStatusIndex, which
+// has nothing to do with entPhysicalIndex
+const ciscoEnvMonSupplyStatusDescrOID string = "1.3.6.1.4.1.9.9.13.1.5.1.2"
 const ciscoEnvMonSupplyStateOID string = "1.3.6.1.4.1.9.9.13.1.5.1.3"
+
 const cefcFruPowerOperStatusOID string = "1.3.6.1.4.1.9.9.117.1.1.2.1.2"
+const entPhysicalContainedInOID string = "1.3.6.1.2.1.47.1.1.1.1.4"
+
+// CISCO-ENTITY-SENSOR-MIB
+const entSensorTypeOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.1"
+const entSensorScaleOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.2"
+const entSensorPrecisionOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.3"
+const entSensorValueOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.4"
+
+// how far up entPhysicalContainedIn we'll go looking for the PSU a sensor belongs to
+const maxContainmentDepth int = 3
 
 var rootCmd = &cobra.Command{
 	Use:   "check_cisco_powersupplies",
@@ -40,6 +60,13 @@ var rootCmd = &cobra.Command{
 		ctx := context.Background() // just here so we can log using slog.Log() with common.LevelTrace
 		slog.SetDefault(slog.With("target", conn.Target))
 
+		powerOperStateMap := NewStateMap(&stateMapFlag, "PowerOperType", defaultPowerOperStatus)
+		envMonStateMap := NewStateMap(&stateMapFlag, "CiscoEnvMonState", defaultEnvMonStatus)
+		if unmatched := stateMapFlag.Unmatched(); len(unmatched) > 0 {
+			slog.Error("--state-map contains states this check doesn't know about", "states", unmatched)
+			os.Exit(1)
+		}
+
 		conn.Version = g.Version3
 		conn.SecurityModel = g.UserSecurityModel
 		conn.MsgFlags = seclevel.Value
@@ -138,7 +165,11 @@ var rootCmd = &cobra.Command{
 		var psuContainers []int
 		var numberOfExpectedPsus int
 		var ciscoEnvMonSupplyState map[int]SnmpCiscoEnvMonState
+		var ciscoEnvMonSupplyStatusDescr map[int]string
+		var envMonRowForPsu map[int]int // ciscoEnvMonSupplyStatusIndex keyed by entPhysicalIndex
 		var cefcFruPowerOperStatus map[int]SnmpPowerOperType
+		var entPhysicalEntries map[int]EntPhysicalEntry
+		var psuWatts map[int]float64
 
 		if do9200Hack {
 			psuIndices = append(psuIndices, 1)
@@ -204,6 +235,23 @@ var rootCmd = &cobra.Command{
 					ciscoEnvMonSupplyState[it_index] = SnmpCiscoEnvMonState(v)
 				}
 
+				// get ciscoEnvMonSupplyStatusDescr, to match the rows to the PSUs
+				result, err = common.BulkWalkToMap(&conn, ciscoEnvMonSupplyStatusDescrOID)
+				if err != nil {
+					slog.Error("BulkWalk of device failed.", "target", &conn.Target, "oid", ciscoEnvMonSupplyStatusDescrOID, "error", err)
+					os.Exit(1)
+				}
+
+				ciscoEnvMonSupplyStatusDescr = make(map[int]string)
+				for it_index, it := range result {
+					v, ok := it.(string)
+					if !ok {
+						slog.Warn("Unable to convert value to string", "oid", ciscoEnvMonSupplyStatusDescrOID, "key", it_index, "raw_value", it)
+						continue
+					}
+					ciscoEnvMonSupplyStatusDescr[it_index] = v
+				}
+
 			} else {
 				slog.Debug("Using cefcFruPowerOperStatus to determine status")
 
@@ -252,6 +300,23 @@ var rootCmd = &cobra.Command{
 				}
 			}
 
+			// get names, models and serials of the PSUs for the long output
+			entPhysicalEntries, err = common.GetEntPhysicalEntries(&conn)
+			if err != nil {
+				slog.Error("Unable to get entPhysicalTable", "error", err)
+				os.Exit(1)
+			}
+
+			if modelRequiresUseOfCiscoEnvMonSupplyStateTable {
+				envMonRowForPsu = mapEnvMonRowsToPsus(psuIndices, entPhysicalEntries, ciscoEnvMonSupplyStatusDescr)
+			}
+
+			psuWatts, err = getPsuWatts(&conn, psuIndices)
+			if err != nil {
+				slog.Error("Unable to get power draw of PSUs", "error", err)
+				os.Exit(1)
+			}
+
 			if modelHasOnlyOnePSU {
 				slog.Debug("Detected model has set modelHasOnlyOnePSU")
 				stackMembers, err := common.GetStackMembers(&conn)
@@ -272,59 +337,253 @@ var rootCmd = &cobra.Command{
 			}
 		} // end do9200Hack else
 
-		numberOfPsus := len(psuIndices)
-		switch {
-		case (numberOfPsus == numberOfExpectedPsus) || (psuExpectedOverrideValue > 0):
-			if numberOfExpectedPsus == 1 {
-				// it's only possible to get here if the device is online.
-				common.ExitPlugin(&IcingaStatus{Value: IcingaOK, Message: fmt.Sprintf("All (%d) PSUs are present and 'ON'.", numberOfExpectedPsus)})
+		// per PSU detail. The 9200 hack never talks to the device, so there's nothing to report.
+		var psuProblems []string
+		var longOutput strings.Builder
+		var perfData strings.Builder
+		psuStatesStatus := IcingaOK
+
+		sort.Ints(psuIndices)
+		for _, it := range psuIndices {
+			if do9200Hack {
+				break
 			}
 
-			// Check the number of PSUs that have the 'on' state
-			numberOfPsusThatAreOn := 0
-			for _, it := range psuIndices {
-				l := slog.With("index", it)
-				if modelRequiresUseOfCiscoEnvMonSupplyStateTable {
-					l.Debug("Detected model has set modelRequiresUseOfCiscoEnvMonSupplyStateTable")
-					l.Debug("Testing psu state, looking for CiscoEnvMonStateNormal", "ciscoEnvMonSupplyState[it]", ciscoEnvMonSupplyState[it])
-					if ciscoEnvMonSupplyState[it] == CiscoEnvMonStateNormal {
-						numberOfPsusThatAreOn += 1
-					}
-				} else {
-					l.Debug("Testing psu status, looking for PowerOperTypeOn", "cefcFruPowerOperStatus[it]", cefcFruPowerOperStatus[it])
-					if cefcFruPowerOperStatus[it] == PowerOperTypeOn {
-						numberOfPsusThatAreOn += 1
+			// a PSU without a state is UNKNOWN, rather than whatever state 0 would map to
+			stateString := "state not reported"
+			stateStatus := IcingaUNKNOWN
+			if modelRequiresUseOfCiscoEnvMonSupplyStateTable {
+				if row, ok := envMonRowForPsu[it]; ok {
+					if state, ok := ciscoEnvMonSupplyState[row]; ok {
+						stateString = strings.TrimPrefix(state.String(), "CiscoEnvMonState")
+						stateStatus = envMonStateMap.Status(state)
 					}
 				}
+			} else if state, ok := cefcFruPowerOperStatus[it]; ok {
+				stateString = strings.TrimPrefix(state.String(), "PowerOperType")
+				stateStatus = powerOperStateMap.Status(state)
+			}
+			if stateStatus > psuStatesStatus {
+				psuStatesStatus = stateStatus
 			}
-			slog.Debug("Finished counting PSUs that have 'on' state.", "numberOfPsusThatAreOn", numberOfPsusThatAreOn, "numberOfExpectedPsus", numberOfExpectedPsus)
 
+			entry := entPhysicalEntries[it]
+			name := entry.Name
+			if name == "" {
+				name = fmt.Sprintf("PSU %d", it)
+			}
+			if stateStatus != IcingaOK {
+				psuProblems = append(psuProblems, fmt.Sprintf("%s is %s", name, stateString))
+			}
+
+			longOutput.WriteString(fmt.Sprintf("[%s] %s: %s", stateStatus, name, stateString))
+			if entry.ModelName != "" {
+				longOutput.WriteString(fmt.Sprintf(", model %s", entry.ModelName))
+			}
+			if entry.SerialNum != "" {
+				longOutput.WriteString(fmt.Sprintf(", serial %s", entry.SerialNum))
+			}
+			if watts, ok := psuWatts[it]; ok {
+				longOutput.WriteString(fmt.Sprintf(", %gW", watts))
+				perfData.WriteString(fmt.Sprintf("'%s'=%gW;;;0; ", name, watts))
+			}
+			longOutput.WriteString("\n")
+		}
+
+		numberOfPsus := len(psuIndices)
+		var exitStatus IcingaStatusVal
+		var exitMsg string
+		switch {
+		case (numberOfPsus == numberOfExpectedPsus) || (psuExpectedOverrideValue > 0):
 			if psuExpectedOverrideValue > 0 {
 				numberOfExpectedPsus = int(psuExpectedOverrideValue)
 				slog.Debug("User set PSU expected value as argument, so we've overwritten numberOfExpectedPsus", "psuExpectedOverrideValue", psuExpectedOverrideValue, "numberOfExpectedPsus", numberOfExpectedPsus)
 			}
 
-			if numberOfPsusThatAreOn == numberOfExpectedPsus {
-				common.ExitPlugin(&IcingaStatus{Value: IcingaOK, Message: fmt.Sprintf("All (%d) PSUs are present and 'ON'.", numberOfExpectedPsus)})
-			} else {
-				common.ExitPlugin(&IcingaStatus{Value: IcingaWARN, Message: "At least one PSU is not 'ON'."})
+			switch {
+			case psuStatesStatus != IcingaOK:
+				exitStatus = psuStatesStatus
+				exitMsg = strings.Join(psuProblems, "; ")
+			case numberOfPsus < numberOfExpectedPsus:
+				// only possible when the user has overriden the expected number
+				exitStatus = IcingaWARN
+				exitMsg = fmt.Sprintf("Only %d PSUs are present (should be %d)", numberOfPsus, numberOfExpectedPsus)
+			default:
+				// with a single PSU, it's only possible to get here if the device is online.
+				exitStatus = IcingaOK
+				exitMsg = fmt.Sprintf("All (%d) PSUs are present and 'ON'.", numberOfExpectedPsus)
 			}
 
 		case numberOfPsus == 1:
-			common.ExitPlugin(&IcingaStatus{Value: IcingaWARN, Message: "Only one PSU is present."})
+			exitStatus, exitMsg = IcingaWARN, "Only one PSU is present."
 		case numberOfPsus < numberOfExpectedPsus:
-			common.ExitPlugin(&IcingaStatus{Value: IcingaWARN, Message: fmt.Sprintf("Only %d PSUs are present (should be %d)", numberOfPsus, numberOfExpectedPsus)})
+			exitStatus, exitMsg = IcingaWARN, fmt.Sprintf("Only %d PSUs are present (should be %d)", numberOfPsus, numberOfExpectedPsus)
 		case numberOfPsus == 0:
-			common.ExitPlugin(&IcingaStatus{Value: IcingaCRITICAL, Message: "SNMP reports all PSUs are absent! (Huh?!)"})
+			exitStatus, exitMsg = IcingaCRITICAL, "SNMP reports all PSUs are absent! (Huh?!)"
 		case numberOfPsus > numberOfExpectedPsus:
-			common.ExitPlugin(&IcingaStatus{Value: IcingaWARN, Message: fmt.Sprintf("More PSUs (%d) than expecting (%d).", numberOfPsus, numberOfExpectedPsus)})
+			exitStatus, exitMsg = IcingaWARN, fmt.Sprintf("More PSUs (%d) than expecting (%d).", numberOfPsus, numberOfExpectedPsus)
 		default:
-			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Plugin error."})
+			exitStatus, exitMsg = IcingaUNKNOWN, "Plugin error."
 		}
 
+		// a failed PSU is worse than a missing one
+		if numberOfPsus != numberOfExpectedPsus && psuStatesStatus > exitStatus {
+			exitStatus = psuStatesStatus
+			exitMsg = fmt.Sprintf("%s; %s", strings.Join(psuProblems, "; "), exitMsg)
+		}
+
+		perfData.WriteString(fmt.Sprintf("'psus'=%d;%d:;;0; ", numberOfPsus, numberOfExpectedPsus))
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
 	},
 }
 
+// defaultPowerOperStatus is used for cefcFRUPowerOperStatus states not given with --state-map
+func defaultPowerOperStatus(state SnmpPowerOperType) IcingaStatusVal {
+	switch state {
+	case PowerOperTypeOn:
+		return IcingaOK
+	case PowerOperTypeFailed, PowerOperTypeOffEnvOther, PowerOperTypeOffEnvTemp, PowerOperTypeOffEnvFan, PowerOperTypeOffCooling:
+		return IcingaCRITICAL
+	default:
+		return IcingaWARN
+	}
+}
+
+// defaultEnvMonStatus is used for ciscoEnvMonSupplyState states not given with --state-map
+func defaultEnvMonStatus(state SnmpCiscoEnvMonState) IcingaStatusVal {
+	switch state {
+	case CiscoEnvMonStateNormal:
+		return IcingaOK
+	case CiscoEnvMonStateCritical, CiscoEnvMonStateShutdown, CiscoEnvMonStateNotFunctioning:
+		return IcingaCRITICAL
+	default:
+		return IcingaWARN
+	}
+}
+
+// mapEnvMonRowsToPsus matches ciscoEnvMonSupplyStatusTable rows to PSU entities. A row whose
+// description is the PSU's entPhysicalDescr or entPhysicalName is matched first, then whatever
+// is left is paired up in index order, as long as there are as many rows as PSUs left.
+func mapEnvMonRowsToPsus(psuIndices []int, entries map[int]EntPhysicalEntry, descr map[int]string) map[int]int {
+	rowForPsu := make(map[int]int)
+	usedRows := make(map[int]bool)
+
+	var rows []int
+	for it_index := range descr {
+		rows = append(rows, it_index)
+	}
+	sort.Ints(rows)
+	psus := slices.Clone(psuIndices)
+	sort.Ints(psus)
+
+	var unmatchedPsus []int
+	for _, it := range psus {
+		matched := false
+		for _, row := range rows {
+			d := strings.TrimSpace(descr[row])
+			if usedRows[row] || d == "" {
+				continue
+			}
+			if strings.EqualFold(d, entries[it].Descr) || strings.EqualFold(d, entries[it].Name) {
+				rowForPsu[it] = row
+				usedRows[row] = true
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			unmatchedPsus = append(unmatchedPsus, it)
+		}
+	}
+
+	var unusedRows []int
+	for _, it := range rows {
+		if !usedRows[it] {
+			unusedRows = append(unusedRows, it)
+		}
+	}
+	if len(unusedRows) != len(unmatchedPsus) {
+		slog.Debug("Unable to pair up ciscoEnvMonSupplyStatusTable rows with PSUs by order", "rows", unusedRows, "psus", unmatchedPsus)
+		return rowForPsu
+	}
+	for it_index, it := range unmatchedPsus {
+		rowForPsu[it] = unusedRows[it_index]
+	}
+	slog.Debug("Matched ciscoEnvMonSupplyStatusTable rows to PSUs", "rowForPsu", rowForPsu)
+
+	return rowForPsu
+}
+
+// getPsuWatts finds the watt sensors in CISCO-ENTITY-SENSOR-MIB that belong to the given PSUs,
+// either directly or through entPhysicalContainedIn. PSUs without such a sensor are left out.
+func getPsuWatts(conn *g.GoSNMP, psuIndices []int) (map[int]float64, error) {
+	isPsu := make(map[int]bool)
+	for _, it := range psuIndices {
+		isPsu[it] = true
+	}
+
+	result, err := common.BulkWalkToMap(conn, entPhysicalContainedInOID)
+	if err != nil {
+		return nil, fmt.Errorf("BulkWalk of %s failed: %w", entPhysicalContainedInOID, err)
+	}
+	entPhysicalContainedIn := make(map[int]int)
+	for it_index, it := range result {
+		v, ok := it.(int)
+		if !ok {
+			slog.Warn("Unable to convert value to int", "oid", entPhysicalContainedInOID, "key", it_index, "raw_value", it)
+			continue
+		}
+		entPhysicalContainedIn[it_index] = v
+	}
+
+	columns := make(map[string]map[int]int)
+	for _, oid := range []string{entSensorTypeOID, entSensorScaleOID, entSensorPrecisionOID, entSensorValueOID} {
+		result, err = common.BulkWalkToMap(conn, oid)
+		if err != nil {
+			return nil, fmt.Errorf("BulkWalk of %s failed: %w", oid, err)
+		}
+		columns[oid] = make(map[int]int)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", oid, "key", it_index, "raw_value", it)
+				continue
+			}
+			columns[oid][it_index] = v
+		}
+	}
+
+	psuWatts := make(map[int]float64)
+	for it_index, it := range columns[entSensorTypeOID] {
+		if SnmpEntSensorDataType(it) != EntSensorDataTypeWatts {
+			continue
+		}
+
+		psu := it_index
+		for depth := 0; depth < maxContainmentDepth && psu != 0 && !isPsu[psu]; depth++ {
+			psu = entPhysicalContainedIn[psu]
+		}
+		if !isPsu[psu] {
+			slog.Debug("Watt sensor is not contained by a PSU, skipping", "index", it_index)
+			continue
+		}
+
+		// @Assumption: a PSU with more than one watt sensor (eg. input and output) reports the last one we see
+		psuWatts[psu] = scaleSensorValue(columns[entSensorValueOID][it_index], SnmpEntSensorDataScale(columns[entSensorScaleOID][it_index]), columns[entSensorPrecisionOID][it_index])
+	}
+
+	return psuWatts, nil
+}
+
+// scaleSensorValue turns a raw entSensorValue into a float in the sensors base unit, eg. a value
+// of 1234 with a precision of 1 becomes 123.4
+func scaleSensorValue(raw int, scale SnmpEntSensorDataScale, precision int) float64 {
+	exponent := 3*(int(scale)-int(EntSensorDataScaleUnits)) - precision
+	return math.Round(float64(raw)*math.Pow10(exponent)*1000) / 1000
+}
+
 func init() {
 	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
 
@@ -347,6 +606,7 @@ func init() {
 
 	// check specific flags
 	rootCmd.PersistentFlags().Uint8Var(&psuExpectedOverrideValue, "expected-psu-override", 0, "Override expected number of PSUs (leave as 0 to determine automatically")
+	rootCmd.PersistentFlags().Var(&stateMapFlag, "state-map", "Override the Icinga state of PSU states, eg. OffAdmin=ok,OffEnvPower=critical (default On/Normal is ok, failures are critical and the rest warn)")
 	rootCmd.PersistentFlags().Uint8Var(&maxTimesToRetryModelQuery, "max-model-query-retries", 2, "How many times to retry the initial query for model (at half second intervals)")
 }
 
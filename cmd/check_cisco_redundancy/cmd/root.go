@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var switchoverWindow time.Duration
+
+const sysUpTimeOID string = "1.3.6.1.2.1.1.3.0" // returns timeticks
+
+// CISCO-RF-MIB (all scalars)
+const cRFStatusUnitIdOID string = "1.3.6.1.4.1.9.9.176.1.1.1.0"
+const cRFStatusUnitStateOID string = "1.3.6.1.4.1.9.9.176.1.1.2.0"
+const cRFStatusPeerUnitIdOID string = "1.3.6.1.4.1.9.9.176.1.1.3.0"
+const cRFStatusPeerUnitStateOID string = "1.3.6.1.4.1.9.9.176.1.1.4.0"
+const cRFStatusLastSwactReasonCodeOID string = "1.3.6.1.4.1.9.9.176.1.1.8.0"
+const cRFStatusFailoverTimeOID string = "1.3.6.1.4.1.9.9.176.1.1.9.0" // returns timestamp (sysUpTime of the last switchover)
+const cRFCfgRedundancyOperModeOID string = "1.3.6.1.4.1.9.9.176.1.2.16.0"
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_redundancy",
+	Short: "Cisco supervisor / route-processor redundancy check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		raw, err := common.SnmpGet(&conn, cRFStatusUnitStateOID)
+		if err != nil {
+			if err.Error() == "SNMP Response: No Such Object" || err.Error() == "SNMP Response: No Such Instance" {
+				common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Device does not support CISCO-RF-MIB (no redundant supervisors?)"})
+			}
+			slog.Error("Error while attempting to get unit state.", "oid", cRFStatusUnitStateOID, "error", err)
+			os.Exit(1)
+		}
+		unitState := SnmpRFState(raw.(int)) // @Assumption
+
+		raw, err = common.SnmpGet(&conn, cRFStatusPeerUnitStateOID)
+		if err != nil {
+			slog.Error("Error while attempting to get peer unit state.", "oid", cRFStatusPeerUnitStateOID, "error", err)
+			os.Exit(1)
+		}
+		peerUnitState := SnmpRFState(raw.(int)) // @Assumption
+
+		var unitId, peerUnitId int
+		raw, err = common.SnmpGet(&conn, cRFStatusUnitIdOID)
+		if err == nil {
+			unitId = raw.(int)
+		}
+		raw, err = common.SnmpGet(&conn, cRFStatusPeerUnitIdOID)
+		if err == nil {
+			peerUnitId = raw.(int)
+		}
+
+		// the rest are nice to have, not every platform populates them
+		redundancyMode := "unknown"
+		raw, err = common.SnmpGet(&conn, cRFCfgRedundancyOperModeOID)
+		if err != nil {
+			slog.Debug("Unable to get redundancy mode, continuing without it", "oid", cRFCfgRedundancyOperModeOID, "error", err)
+		} else if v, ok := raw.(int); ok {
+			redundancyMode = strings.TrimPrefix(SnmpRFMode(v).String(), "RFMode")
+		}
+
+		swactReason := RFSwactReasonNotKnown
+		raw, err = common.SnmpGet(&conn, cRFStatusLastSwactReasonCodeOID)
+		if err != nil {
+			slog.Debug("Unable to get last switchover reason, continuing without it", "oid", cRFStatusLastSwactReasonCodeOID, "error", err)
+		} else if v, ok := raw.(int); ok {
+			swactReason = SnmpRFSwactReasonType(v)
+		}
+
+		var sinceSwitchover time.Duration
+		hadSwitchover := false
+		raw, err = common.SnmpGet(&conn, cRFStatusFailoverTimeOID)
+		if err != nil {
+			slog.Debug("Unable to get last switchover time, continuing without it", "oid", cRFStatusFailoverTimeOID, "error", err)
+		} else if failoverTime, ok := raw.(uint32); ok && failoverTime != 0 {
+			raw, err = common.SnmpGet(&conn, sysUpTimeOID)
+			if err != nil {
+				slog.Error("Error while attempting to get sysUpTime.", "oid", sysUpTimeOID, "error", err)
+				os.Exit(1)
+			}
+			sysUpTime := raw.(uint32) // @Assumption
+
+			// both are in hundredths of a second
+			hadSwitchover = true
+			sinceSwitchover = time.Duration(sysUpTime-failoverTime) * 10 * time.Millisecond
+		}
+		slog.Debug("Collected redundancy status", "unitState", unitState, "peerUnitState", peerUnitState, "redundancyMode", redundancyMode, "swactReason", swactReason, "sinceSwitchover", sinceSwitchover)
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var exitMsg strings.Builder
+		var longOutput strings.Builder
+
+		unitStateString := strings.TrimPrefix(unitState.String(), "RFState")
+		peerUnitStateString := strings.TrimPrefix(peerUnitState.String(), "RFState")
+
+		switch peerUnitState {
+		case RFStateStandbyHot:
+			exitMsg.WriteString(fmt.Sprintf("Peer unit %d is %s", peerUnitId, peerUnitStateString))
+		case RFStateDisabled, RFStateNotKnown:
+			exitStatus = IcingaCRITICAL
+			exitMsg.WriteString(fmt.Sprintf("No standby unit (peer is %s)", peerUnitStateString))
+		default:
+			exitStatus = IcingaCRITICAL
+			exitMsg.WriteString(fmt.Sprintf("Peer unit %d is %s, not StandbyHot", peerUnitId, peerUnitStateString))
+		}
+
+		if unitState != RFStateActive && exitStatus != IcingaCRITICAL {
+			exitStatus = IcingaWARN
+			exitMsg.WriteString(fmt.Sprintf("; this unit (%d) is %s", unitId, unitStateString))
+		}
+
+		swactReasonString := strings.TrimPrefix(swactReason.String(), "RFSwactReason")
+		if hadSwitchover && sinceSwitchover < switchoverWindow {
+			if exitStatus != IcingaCRITICAL {
+				exitStatus = IcingaWARN
+			}
+			exitMsg.WriteString(fmt.Sprintf("; switchover %s ago (%s)", sinceSwitchover.Round(time.Second), swactReasonString))
+		}
+
+		longOutput.WriteString(fmt.Sprintf("Unit %d: %s\n", unitId, unitStateString))
+		longOutput.WriteString(fmt.Sprintf("Peer unit %d: %s\n", peerUnitId, peerUnitStateString))
+		longOutput.WriteString(fmt.Sprintf("Redundancy mode: %s\n", redundancyMode))
+		if hadSwitchover {
+			longOutput.WriteString(fmt.Sprintf("Last switchover: %s ago (%s)\n", sinceSwitchover.Round(time.Second), swactReasonString))
+		} else {
+			longOutput.WriteString("Last switchover: none since boot\n")
+		}
+
+		var perfData string
+		if hadSwitchover {
+			perfData = fmt.Sprintf("'since_switchover'=%.0fs;%.0f:;;0;", sinceSwitchover.Seconds(), switchoverWindow.Seconds())
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg.String(), LongOutput: longOutput.String(), PerfData: perfData})
+
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().DurationVar(&switchoverWindow, "switchover-window", 24*time.Hour, "Warn if a switchover happened more recently than this (eg. 30m, 24h)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
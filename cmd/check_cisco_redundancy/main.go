@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/jamiereid/go-icingaplugins/cmd/check_cisco_redundancy/cmd"
+)
+
+func main() {
+	cmd.Execute()
+}
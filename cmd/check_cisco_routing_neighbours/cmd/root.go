@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var protocols []string
+var expectedOspfNeighbours uint
+var expectedEigrpNeighbours uint
+var expectedNeighbours []string
+var allowTwoWay bool
+
+// OSPF-MIB, ospfNbrTable indexed by ospfNbrIpAddr.ospfNbrAddressLessIndex
+const ospfNbrRtrIdOID string = "1.3.6.1.2.1.14.10.1.3"
+const ospfNbrStateOID string = "1.3.6.1.2.1.14.10.1.6"
+
+// CISCO-EIGRP-MIB, cEigrpPeerTable indexed by cEigrpVpnId.cEigrpAsNumber.cEigrpHandle
+const cEigrpPeerAddrOID string = "1.3.6.1.4.1.9.9.449.1.4.1.1.3"
+const cEigrpPeerIfIndexOID string = "1.3.6.1.4.1.9.9.449.1.4.1.1.4"
+
+// IP-MIB, ipAddrTable indexed by ipAdEntAddr
+const ipAdEntIfIndexOID string = "1.3.6.1.2.1.4.20.1.2"
+const ipAdEntNetMaskOID string = "1.3.6.1.2.1.4.20.1.3"
+
+type routingNeighbour struct {
+	protocol  string
+	address   string
+	routerID  string
+	asNumber  string
+	ifIndex   int
+	ospfState SnmpOspfNbrState
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_routing_neighbours",
+	Short: "Cisco OSPF and EIGRP neighbour adjacency check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		checkOspf := false
+		checkEigrp := false
+		for _, it := range protocols {
+			switch strings.ToLower(it) {
+			case "ospf":
+				checkOspf = true
+			case "eigrp":
+				checkEigrp = true
+			default:
+				slog.Error("Unknown protocol, valid options are: ospf, eigrp", "protocol", it)
+				os.Exit(1)
+			}
+		}
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// get ifName
+		ifName, err := common.GetIfNames(&conn)
+		if err != nil {
+			slog.Error("Unable to get interface names.", "error", err)
+			os.Exit(1)
+		}
+
+		var neighbours []*routingNeighbour
+
+		if checkOspf {
+			slog.Debug("Beginning attempt to get OSPF neighbours", "oid", ospfNbrStateOID)
+			result, err := common.BulkWalkToStringMap(&conn, ospfNbrStateOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", ospfNbrStateOID, "error", err)
+				os.Exit(1)
+			}
+			ospfNeighbours := make(map[string]*routingNeighbour)
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", ospfNbrStateOID, "key", it_index, "raw_value", it)
+					continue
+				}
+
+				// index is a.b.c.d.addressLessIndex
+				parts := strings.Split(it_index, ".")
+				if len(parts) != 5 {
+					slog.Warn("Unexpected index format", "oid", ospfNbrStateOID, "key", it_index)
+					continue
+				}
+				neighbour := &routingNeighbour{protocol: "OSPF", address: strings.Join(parts[:4], "."), ospfState: SnmpOspfNbrState(v)}
+
+				// for unnumbered interfaces the address less index is the ifIndex
+				if addressLessIndex, err := strconv.Atoi(parts[4]); err == nil && addressLessIndex != 0 {
+					neighbour.ifIndex = addressLessIndex
+				}
+				ospfNeighbours[it_index] = neighbour
+			}
+
+			result, err = common.BulkWalkToStringMap(&conn, ospfNbrRtrIdOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", ospfNbrRtrIdOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(string)
+				if !ok {
+					slog.Warn("Unable to convert value to string", "oid", ospfNbrRtrIdOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if neighbour, ok := ospfNeighbours[it_index]; ok {
+					neighbour.routerID = v
+				}
+			}
+
+			// numbered neighbours don't tell us the interface, so find the local subnet they're in
+			if len(ospfNeighbours) > 0 {
+				result, err = common.BulkWalkToStringMap(&conn, ipAdEntIfIndexOID)
+				if err != nil {
+					slog.Error("BulkWalk of device failed.", "oid", ipAdEntIfIndexOID, "error", err)
+					os.Exit(1)
+				}
+				ipAdEntIfIndex := make(map[string]int)
+				for it_index, it := range result {
+					v, ok := it.(int)
+					if !ok {
+						slog.Warn("Unable to convert value to int", "oid", ipAdEntIfIndexOID, "key", it_index, "raw_value", it)
+						continue
+					}
+					ipAdEntIfIndex[it_index] = v
+				}
+
+				result, err = common.BulkWalkToStringMap(&conn, ipAdEntNetMaskOID)
+				if err != nil {
+					slog.Error("BulkWalk of device failed.", "oid", ipAdEntNetMaskOID, "error", err)
+					os.Exit(1)
+				}
+				var localNetworks []*net.IPNet
+				localNetworkIfIndex := make(map[string]int)
+				for it_index, it := range result {
+					v, ok := it.(string)
+					if !ok {
+						slog.Warn("Unable to convert value to string", "oid", ipAdEntNetMaskOID, "key", it_index, "raw_value", it)
+						continue
+					}
+					ip := net.ParseIP(it_index).To4()
+					mask := net.ParseIP(v).To4()
+					if ip == nil || mask == nil {
+						continue
+					}
+					network := &net.IPNet{IP: ip.Mask(net.IPMask(mask)), Mask: net.IPMask(mask)}
+					localNetworks = append(localNetworks, network)
+					localNetworkIfIndex[network.String()] = ipAdEntIfIndex[it_index]
+				}
+
+				for _, it := range ospfNeighbours {
+					if it.ifIndex != 0 {
+						continue
+					}
+					ip := net.ParseIP(it.address)
+					for _, network := range localNetworks {
+						if network.Contains(ip) {
+							it.ifIndex = localNetworkIfIndex[network.String()]
+							break
+						}
+					}
+				}
+			}
+
+			for _, it := range ospfNeighbours {
+				neighbours = append(neighbours, it)
+			}
+		}
+
+		if checkEigrp {
+			slog.Debug("Beginning attempt to get EIGRP neighbours", "oid", cEigrpPeerAddrOID)
+			result, err := common.BulkWalkToStringMap(&conn, cEigrpPeerAddrOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cEigrpPeerAddrOID, "error", err)
+				os.Exit(1)
+			}
+			eigrpNeighbours := make(map[string]*routingNeighbour)
+			for it_index, it := range result {
+				v, ok := it.(string)
+				if !ok {
+					slog.Warn("Unable to convert value to string", "oid", cEigrpPeerAddrOID, "key", it_index, "raw_value", it)
+					continue
+				}
+
+				// index is vpnId.asNumber.handle
+				parts := strings.Split(it_index, ".")
+				if len(parts) != 3 {
+					slog.Warn("Unexpected index format", "oid", cEigrpPeerAddrOID, "key", it_index)
+					continue
+				}
+
+				// the address is raw octets
+				address := net.IP([]byte(v))
+				if len(address) != net.IPv4len && len(address) != net.IPv6len {
+					slog.Warn("Unexpected peer address length", "oid", cEigrpPeerAddrOID, "key", it_index, "raw_value", []byte(v))
+					continue
+				}
+				eigrpNeighbours[it_index] = &routingNeighbour{protocol: "EIGRP", address: address.String(), asNumber: parts[1]}
+			}
+
+			result, err = common.BulkWalkToStringMap(&conn, cEigrpPeerIfIndexOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", cEigrpPeerIfIndexOID, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", cEigrpPeerIfIndexOID, "key", it_index, "raw_value", it)
+					continue
+				}
+				if neighbour, ok := eigrpNeighbours[it_index]; ok {
+					neighbour.ifIndex = v
+				}
+			}
+
+			for _, it := range eigrpNeighbours {
+				neighbours = append(neighbours, it)
+			}
+		}
+
+		sort.Slice(neighbours, func(i, j int) bool {
+			if neighbours[i].protocol != neighbours[j].protocol {
+				return neighbours[i].protocol > neighbours[j].protocol // OSPF first
+			}
+			return neighbours[i].address < neighbours[j].address
+		})
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var exitMsg strings.Builder
+		var longOutput strings.Builder
+
+		ospfCount := 0
+		ospfFullCount := 0
+		eigrpCount := 0
+		var notFull []string
+		seen := make(map[string]bool)
+
+		for _, it := range neighbours {
+			neighbourStatus := IcingaOK
+
+			interfaceName := ifName[it.ifIndex]
+			if interfaceName == "" {
+				interfaceName = "unknown interface"
+			}
+
+			var state string
+			switch it.protocol {
+			case "OSPF":
+				ospfCount += 1
+				state = strings.ToLower(strings.TrimPrefix(it.ospfState.String(), "OspfNbrState"))
+				switch {
+				case it.ospfState == OspfNbrStateFull:
+					ospfFullCount += 1
+				case it.ospfState == OspfNbrStateTwoWay && allowTwoWay:
+					ospfFullCount += 1
+				default:
+					neighbourStatus = IcingaCRITICAL
+					notFull = append(notFull, fmt.Sprintf("%s (%s)", it.address, interfaceName))
+				}
+				seen[it.routerID] = true
+				longOutput.WriteString(fmt.Sprintf("[%s] OSPF %s (router-id %s) on %s is %s\n", neighbourStatus, it.address, it.routerID, interfaceName, state))
+			case "EIGRP":
+				// peers only appear in the EIGRP peer table while they are up
+				eigrpCount += 1
+				longOutput.WriteString(fmt.Sprintf("[%s] EIGRP AS %s %s on %s is up\n", neighbourStatus, it.asNumber, it.address, interfaceName))
+			}
+			seen[it.address] = true
+
+			if neighbourStatus > exitStatus {
+				exitStatus = neighbourStatus
+			}
+		}
+
+		var missing []string
+		for _, it := range expectedNeighbours {
+			if !seen[it] {
+				missing = append(missing, it)
+			}
+		}
+		if len(missing) > 0 {
+			exitStatus = IcingaCRITICAL
+			exitMsg.WriteString(fmt.Sprintf("Neighbours missing: %s; ", strings.Join(missing, ", ")))
+			for _, it := range missing {
+				longOutput.WriteString(fmt.Sprintf("[%s] %s is not a neighbour\n", IcingaCRITICAL, it))
+			}
+		}
+
+		if len(notFull) > 0 {
+			exitMsg.WriteString(fmt.Sprintf("OSPF neighbours not full: %s; ", strings.Join(notFull, ", ")))
+		}
+
+		if checkOspf && expectedOspfNeighbours > 0 && uint(ospfFullCount) < expectedOspfNeighbours {
+			exitStatus = IcingaCRITICAL
+			exitMsg.WriteString(fmt.Sprintf("Only %d OSPF neighbours are full (should be %d); ", ospfFullCount, expectedOspfNeighbours))
+		}
+
+		if checkEigrp && expectedEigrpNeighbours > 0 && uint(eigrpCount) < expectedEigrpNeighbours {
+			exitStatus = IcingaCRITICAL
+			exitMsg.WriteString(fmt.Sprintf("Only %d EIGRP neighbours are up (should be %d); ", eigrpCount, expectedEigrpNeighbours))
+		}
+
+		if exitStatus == IcingaOK {
+			var counts []string
+			if checkOspf {
+				counts = append(counts, fmt.Sprintf("%d OSPF neighbours are full", ospfFullCount))
+			}
+			if checkEigrp {
+				counts = append(counts, fmt.Sprintf("%d EIGRP neighbours are up", eigrpCount))
+			}
+			exitMsg.WriteString(strings.Join(counts, " and "))
+		}
+
+		perfData := fmt.Sprintf("'ospf_neighbours'=%d;;;0; 'ospf_neighbours_full'=%d;;%s;0; 'eigrp_neighbours'=%d;;%s;0;",
+			ospfCount, ospfFullCount, lowerBound(expectedOspfNeighbours), eigrpCount, lowerBound(expectedEigrpNeighbours))
+
+		// @Speed
+		exitMsgString := strings.TrimSuffix(exitMsg.String(), "; ")
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsgString, LongOutput: longOutput.String(), PerfData: perfData})
+
+	},
+}
+
+// lowerBound renders a threshold as an icinga range that alerts when below the value
+func lowerBound(threshold uint) string {
+	if threshold == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:", threshold)
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().StringSliceVar(&protocols, "protocol", []string{"ospf", "eigrp"}, "Routing protocols to check (ospf, eigrp)")
+	rootCmd.PersistentFlags().UintVar(&expectedOspfNeighbours, "expected-ospf", 0, "Number of OSPF neighbours expected to be full (0 to disable)")
+	rootCmd.PersistentFlags().UintVar(&expectedEigrpNeighbours, "expected-eigrp", 0, "Number of EIGRP neighbours expected to be up (0 to disable)")
+	rootCmd.PersistentFlags().StringSliceVar(&expectedNeighbours, "neighbour", nil, "Neighbour addresses (or OSPF router IDs) that must be present (can be repeated or comma separated)")
+	rootCmd.PersistentFlags().BoolVar(&allowTwoWay, "allow-two-way", false, "Treat OSPF neighbours in 2-Way as healthy (DROTHER to DROTHER on broadcast segments)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
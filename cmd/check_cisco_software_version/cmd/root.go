@@ -0,0 +1,271 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+var maxTimesToRetryModelQuery uint8
+
+var policyFile string
+
+const sysDescrOID string = "1.3.6.1.2.1.1.1.0"
+const entPhysicalSoftwareRevOID string = "1.3.6.1.2.1.47.1.1.1.1.10"
+
+// CISCO-STACKWISE-MIB, cswSwitchInfoTable indexed by entPhysicalIndex
+const cswSwitchNumCurrentOID string = "1.3.6.1.4.1.9.9.500.1.2.1.1.1"
+
+// eg. "Cisco IOS Software [Cupertino], Catalyst L3 Switch Software (CAT9K_IOSXE), Version 17.9.4a, RELEASE SOFTWARE (fc5)"
+var patternForSysDescrVersion = regexp.MustCompile(`Version ([^ ,]+)`)
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_software_version",
+	Short: "Cisco software version compliance check plugin",
+	Long: `Compares the software running on every stack member against a policy file.
+
+The policy file is YAML (like check_snmp_table's definitions), mapping a model to
+the list of approved versions, eg.
+
+  9300: ["17.9.4a", "17.12.3"]
+  2960X:
+    - 15.2(7)E9
+
+JSON is valid YAML, so policy files written as JSON still work.
+
+Models are matched by family (so "9300" also covers "C9300-48P").`,
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		policy, err := loadPolicy(policyFile)
+		if err != nil {
+			slog.Error("Unable to load policy file.", "file", policyFile, "error", err)
+			os.Exit(1)
+		}
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		var (
+			deviceModelFamily *CiscoModelFamily
+			rawDeviceModel    string
+		)
+
+		slog.Debug("Begin attempt to get model from device.")
+		for attempt := 1; attempt <= int(maxTimesToRetryModelQuery); attempt++ {
+			deviceModelFamily, rawDeviceModel, err = common.GetDeviceModel(&conn)
+			if err != nil {
+				slog.Error("Problem when attempting to get the model of the device.", "error", err)
+				os.Exit(1)
+			}
+
+			if rawDeviceModel != "" {
+				break // success
+			}
+
+			slog.Warn("Got empty model string; retrying...", "attempt", attempt)
+			time.Sleep(500 * time.Millisecond)
+		}
+		slog.Debug("End attempt to get model from device.")
+
+		if *deviceModelFamily == CiscoModelFamilyUnknown {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: fmt.Sprintf("This application doesn't yet know how to handle this model (%s).", rawDeviceModel)})
+		}
+		slog.SetDefault(slog.With("model", rawDeviceModel, "modelFamily", deviceModelFamily))
+
+		allowedVersions, ok := policy[*deviceModelFamily]
+		if !ok {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: fmt.Sprintf("Policy file has no entry for %s (%s)", deviceModelFamily, rawDeviceModel)})
+		}
+
+		stackMembers, err := common.GetStackMembers(&conn)
+		if err != nil {
+			slog.Error("Problem when attempting to get the stack members of the device.", "error", err)
+			os.Exit(1)
+		}
+		sort.Ints(stackMembers)
+		if len(stackMembers) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "No stack members found"})
+		}
+
+		err = conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// sysDescr is the fallback for chassis that don't populate entPhysicalSoftwareRev
+		var sysDescrVersion string
+		raw, err := common.SnmpGet(&conn, sysDescrOID)
+		if err != nil {
+			slog.Error("Error while attempting to get sysDescr.", "oid", sysDescrOID, "error", err)
+			os.Exit(1)
+		}
+		if v, ok := raw.(string); ok {
+			if m := patternForSysDescrVersion.FindStringSubmatch(v); m != nil {
+				sysDescrVersion = m[1]
+			}
+		}
+		slog.Debug("Parsed version from sysDescr", "version", sysDescrVersion)
+
+		memberVersions := make(map[int]string)
+		memberNames := make(map[int]string)
+		for _, it := range stackMembers {
+			// name members by their switch number, which isn't there on devices that aren't stacks
+			memberNames[it] = fmt.Sprintf("entPhysicalIndex %d", it)
+			raw, err := common.SnmpGet(&conn, fmt.Sprintf("%s.%d", cswSwitchNumCurrentOID, it))
+			if err != nil {
+				slog.Debug("Unable to get cswSwitchNumCurrent, naming member by entPhysicalIndex", "entPhysicalIndex", it, "error", err)
+			} else {
+				switch v := raw.(type) {
+				case int:
+					memberNames[it] = fmt.Sprintf("member %d", v)
+				case uint32:
+					memberNames[it] = fmt.Sprintf("member %d", v)
+				}
+			}
+
+			oid := fmt.Sprintf("%s.%d", entPhysicalSoftwareRevOID, it)
+			raw, err = common.SnmpGet(&conn, oid)
+			if err != nil {
+				slog.Debug("Unable to get entPhysicalSoftwareRev, falling back to sysDescr", "oid", oid, "error", err)
+			} else if v, ok := raw.(string); ok {
+				memberVersions[it] = strings.TrimSpace(v)
+			}
+			if memberVersions[it] == "" {
+				memberVersions[it] = sysDescrVersion
+			}
+		}
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var longOutput strings.Builder
+		var problems []string
+		var seenVersions []string
+
+		for _, it := range stackMembers {
+			version := memberVersions[it]
+			if version != "" && !slices.Contains(seenVersions, version) {
+				seenVersions = append(seenVersions, version)
+			}
+
+			memberStatus := IcingaOK
+			if version == "" {
+				memberStatus = IcingaUNKNOWN
+				problems = append(problems, fmt.Sprintf("%s version unknown", memberNames[it]))
+			} else if !slices.Contains(allowedVersions, version) {
+				memberStatus = IcingaWARN
+				problems = append(problems, fmt.Sprintf("%s runs %s", memberNames[it], version))
+			}
+			if memberStatus > exitStatus {
+				exitStatus = memberStatus
+			}
+
+			longOutput.WriteString(fmt.Sprintf("[%s] %s (entPhysicalIndex %d): %s\n", memberStatus, memberNames[it], it, version))
+		}
+
+		if len(seenVersions) > 1 {
+			exitStatus = IcingaCRITICAL
+			problems = append([]string{fmt.Sprintf("mixed versions in stack (%s)", strings.Join(seenVersions, ", "))}, problems...)
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("%s is approved for %s", seenVersions[0], deviceModelFamily)
+		} else {
+			exitMsg = fmt.Sprintf("%s; approved: %s", strings.Join(problems, "; "), strings.Join(allowedVersions, ", "))
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String()})
+
+	},
+}
+
+// loadPolicy reads the policy file and keys it by model family, so the file
+// can use any model string NewCiscoModelFamily understands.
+func loadPolicy(path string) (map[CiscoModelFamily][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string][]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %w", path, err)
+	}
+
+	policy := make(map[CiscoModelFamily][]string)
+	for model, versions := range raw {
+		family := NewCiscoModelFamily(model)
+		if family == CiscoModelFamilyUnknown {
+			return nil, fmt.Errorf("Unrecognised model %q in %s", model, path)
+		}
+		policy[family] = append(policy[family], versions...)
+	}
+
+	return policy, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().Uint8Var(&maxTimesToRetryModelQuery, "max-model-query-retries", 2, "How many times to retry the initial query for model (at half second intervals)")
+	rootCmd.PersistentFlags().StringVarP(&policyFile, "policy", "P", "", "Path to the YAML file of approved versions per model (required)")
+	rootCmd.MarkPersistentFlagRequired("policy")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,13 +28,60 @@ var seclevel SnmpV3MsgFlagsValue
 var authmode SnmpV3AuthProtocolValue
 var privmode SnmpV3PrivProtocolValue
 var maxTimesToRetryModelQuery uint8
+var svlInterfacesFilter string
+var expectedMembers int
+var stateFile string
+var masterChangeWindow time.Duration
+var patternForSvlInterfaces *regexp.Regexp
+var stateMapFlag StateMapValue
+
+const cswSwitchNumCurrentOID string = "CISCO-STACKWISE-MIB::cswSwitchNumCurrent"
+const cswSwitchRoleOID string = "CISCO-STACKWISE-MIB::cswSwitchRole"
+const cswSwitchSwPriorityOID string = "CISCO-STACKWISE-MIB::cswSwitchSwPriority"
+const cswSwitchStateOID string = "CISCO-STACKWISE-MIB::cswSwitchState"
+const cswSwitchMacAddressOID string = "CISCO-STACKWISE-MIB::cswSwitchMacAddress"
+const cswStatePortOperStatusOID string = "CISCO-STACKWISE-MIB::cswStackPortOperStatus"
+const cswStackPortNeighborOID string = "CISCO-STACKWISE-MIB::cswStackPortNeighbor" // returns entPhysicalIndex of the neighbouring switch, or 0
+const cswRingRedundantOID string = "CISCO-STACKWISE-MIB::cswRingRedundant.0"
+const ifDescrOID string = "IF-MIB::ifDescr"
+const ifOperStatusOID string = "IF-MIB::ifOperStatus"
+const entPhysicalContainedInOID string = "ENTITY-MIB::entPhysicalContainedIn"
+const entPhysicalClassOID string = "ENTITY-MIB::entPhysicalClass"
+const entAliasMappingIdentifierOID string = "ENTITY-MIB::entAliasMappingIdentifier" // index is entPhysicalIndex.entAliasLogicalIndexOrZero
+const ifIndexOID string = "1.3.6.1.2.1.2.2.1.1"                                     // what entAliasMappingIdentifier points to for an interface
+
+// how far up entPhysicalContainedIn we'll go looking for the chassis a stack port is in
+const maxContainmentDepth int = 8
+
+// CISCO-STACKWISE-MIB, cswDistrStackPhyPortInfoTable indexed by
+// entPhysicalIndex.cswDistrStackLinkBundleIdentifier.cswDistrStackPhyPort, where the port name
+// is a length followed by its characters (not to be confused with cswStackPowerPortInfoTable)
+const cswDistrStackPhyPortOperStatusOID string = "CISCO-STACKWISE-MIB::cswDistrStackPhyPortOperStatus"
+
+type stackMember struct {
+	number   int
+	role     SnmpCswSwitchRole
+	priority int
+	mac      string
+	state    SnmpCswSwitchState
+}
+
+type stackPort struct {
+	name      string
+	member    int
+	port      int
+	status    SnmpCswStackPortOperStatus
+	neighbour int // switch number, 0 if nothing is connected
+}
 
-const cswSwitchStateOID string = "1.3.6.1.4.1.9.9.500.1.2.1.1.6"
-const cswStatePortOperStatusOID string = "1.3.6.1.4.1.9.9.500.1.2.2.1.1"
-const ifDescrOID = "1.3.6.1.2.1.2.2.1.2"
-const ifAliasOID string = "1.3.6.1.2.1.31.1.1.1.18"
-const ifAdminStatusOID string = "1.3.6.1.2.1.2.2.1.7"
-const ifOperStatusOID string = "1.3.6.1.2.1.2.2.1.8"
+// @Assumption: stack ports are named like StackPort1/2 or StackSub-St1-2 (switch 1, port 2). The
+// switch is taken from ENTITY-MIB where possible, so this is only relied on for the port number.
+var patternForStackPortName = regexp.MustCompile(`(\d+)[/-](\d+)$`)
+
+type svlInterface struct {
+	name   string
+	status SnmpIfOperStatus
+}
 
 var rootCmd = &cobra.Command{
 	Use:   "check_cisco_stackmodules",
@@ -41,6 +93,22 @@ var rootCmd = &cobra.Command{
 		slog.SetDefault(slog.With("target", conn.Target))
 		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
 
+		if svlInterfacesFilter != "" {
+			var err error
+			patternForSvlInterfaces, err = regexp.Compile(svlInterfacesFilter)
+			if err != nil {
+				slog.Error("Unable to compile SVL interface pattern.", "pattern", svlInterfacesFilter, "error", err)
+				os.Exit(1)
+			}
+		}
+
+		switchStateMap := NewStateMap(&stateMapFlag, "SnmpCswSwitchState", defaultSwitchStateStatus)
+		stackPortStateMap := NewStateMap(&stateMapFlag, "SnmpCswStackPortOperStatus", defaultStackPortStatus)
+		if unmatched := stateMapFlag.Unmatched(); len(unmatched) > 0 {
+			slog.Error("--state-map contains states this check doesn't know about", "states", unmatched)
+			os.Exit(1)
+		}
+
 		conn.Version = g.Version3
 		conn.SecurityModel = g.UserSecurityModel
 		conn.MsgFlags = seclevel.Value
@@ -140,10 +208,64 @@ var rootCmd = &cobra.Command{
 		}
 		slog.Debug("We have the switch module states.")
 
+		// get cswSwitchNumCurrent, cswSwitchRole, cswSwitchSwPriority (switch module details)
+		memberInts := make(map[string]map[int]int)
+		for _, oid := range []string{cswSwitchNumCurrentOID, cswSwitchRoleOID, cswSwitchSwPriorityOID} {
+			result, err := common.BulkWalkToMap(&conn, oid)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", oid, "error", err)
+				os.Exit(1)
+			}
+			memberInts[oid] = make(map[int]int)
+			for it_index, it := range result {
+				var v int
+				switch it := it.(type) {
+				case int:
+					v = it
+				case uint32:
+					v = int(it)
+				default:
+					slog.Warn("Unable to convert value to int", "oid", oid, "key", it_index, "raw_value", it)
+					continue
+				}
+				memberInts[oid][it_index] = v
+			}
+		}
+
+		// get cswSwitchMacAddress
+		result, err = common.BulkWalkToMap(&conn, cswSwitchMacAddressOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cswSwitchMacAddressOID, "error", err)
+			os.Exit(1)
+		}
+		cswSwitchMacAddress := make(map[int]string)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", cswSwitchMacAddressOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cswSwitchMacAddress[it_index] = formatMacAddress(v)
+		}
+
+		var members []stackMember
+		for it_index, it := range cswSwitchState {
+			members = append(members, stackMember{
+				number:   memberInts[cswSwitchNumCurrentOID][it_index],
+				role:     SnmpCswSwitchRole(memberInts[cswSwitchRoleOID][it_index]),
+				priority: memberInts[cswSwitchSwPriorityOID][it_index],
+				mac:      cswSwitchMacAddress[it_index],
+				state:    it,
+			})
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].number < members[j].number })
+		slog.Debug("We have the switch module details.", "members", len(members))
+
 		// Assume everything is ok, then check this assumption (See Exit below is changing this code)
 		var exitStatus IcingaStatusVal = IcingaOK
-		var stackPortStatuses []SnmpCswStackPortOperStatus
-		var svlIfStatuses []SnmpIfOperStatus
+		var stackPorts []stackPort
+		ringRedundant := true
+		var svlInterfaces []svlInterface
 		if traditionalStack {
 			// get cswStackPortOperStatus (stack port state)
 			slog.Debug("Beginning attempt to get stack ports operational statuses", "traditionalStack", traditionalStack, "oid", cswStatePortOperStatusOID)
@@ -162,99 +284,236 @@ var rootCmd = &cobra.Command{
 				cswStackPortOperStatus[it_index] = SnmpCswStackPortOperStatus(v)
 			}
 
-			for _, it := range cswStackPortOperStatus {
-				if it != SnmpCswStackPortOperStatusUp && exitStatus != IcingaWARN {
-					exitStatus = IcingaWARN
-				}
-				stackPortStatuses = append(stackPortStatuses, it)
-			}
-		} else { // @Assumption: stackwise virtual
-			// get all the interface descriptions, and find the ones that *likely* the SVLs
-			// unfortunatly, I haven't found a MIB that will clearly tell us the members, so
-			// we do the best we can - which isn't that good :(
-
-			slog.Debug("Beginning attempt to get stack ports operational statuses on what we're assuming is a stackwise virtual stack", "traditionalStack", traditionalStack)
-			result, err := common.BulkWalkToMap(&conn, ifAliasOID)
+			// get cswStackPortNeighbor
+			result, err = common.BulkWalkToMap(&conn, cswStackPortNeighborOID)
 			if err != nil {
-				slog.Error("BulkWalk of device failed.", "oid", ifAliasOID, "error", err)
+				slog.Error("BulkWalk of device failed.", "oid", cswStackPortNeighborOID, "error", err)
 				os.Exit(1)
 			}
-			interestingStrings := []string{"svl", "vsl"}
-			var svlInterfaceIDs []int
+			cswStackPortNeighbor := make(map[int]int)
 			for it_index, it := range result {
-				strIt, ok := it.(string)
+				v, ok := it.(int)
 				if !ok {
-					slog.Warn("Skipping key, value is not a string", "key", it_index)
+					slog.Warn("Unable to convert value to int", "oid", cswStackPortNeighborOID, "key", it_index, "raw_value", it)
 					continue
 				}
-
-				strIt = strings.ToLower(strIt)
-
-				for _, needle := range interestingStrings {
-					if strings.Contains(strIt, needle) {
-						svlInterfaceIDs = append(svlInterfaceIDs, it_index)
-						break
-					}
-				}
+				cswStackPortNeighbor[it_index] = v
 			}
 
-			// get ifAdminStatus
-			result, err = common.BulkWalkToMap(&conn, ifAdminStatusOID)
+			ifName, err := common.GetIfNames(&conn)
 			if err != nil {
-				slog.Error("BulkWalk of device failed.", "oid", ifAdminStatusOID, "error", err)
+				slog.Error("Unable to get interface names.", "error", err)
 				os.Exit(1)
 			}
-			ifAdminStatus := make(map[int]SnmpIfAdminStatus)
-			for it_index, it := range result {
-				v, ok := it.(int)
-				if !ok {
-					slog.Warn("Unable to convert value to int", "oid", ifAdminStatusOID, "key", it_index, "raw_value", it)
-					continue
+
+			// both tables are indexed by entPhysicalIndex, so this is how we get from
+			// cswStackPortNeighbor to a switch number
+			switchNumberByEntity := memberInts[cswSwitchNumCurrentOID]
+
+			switchNumberByIfIndex, err := stackPortSwitchNumbers(&conn, switchNumberByEntity)
+			if err != nil {
+				slog.Debug("Unable to find the switch of each stack port in ENTITY-MIB, falling back to the port names", "error", err)
+			}
+
+			for it_index, it := range cswStackPortOperStatus {
+				if status := stackPortStateMap.Status(it); status > exitStatus {
+					exitStatus = status
+				}
+
+				port := stackPort{name: ifName[it_index], member: switchNumberByIfIndex[it_index], status: it, neighbour: switchNumberByEntity[cswStackPortNeighbor[it_index]]}
+				if port.name == "" {
+					port.name = fmt.Sprintf("ifIndex %d", it_index)
+				}
+				if m := patternForStackPortName.FindStringSubmatch(port.name); m != nil {
+					if port.member == 0 {
+						port.member, _ = strconv.Atoi(m[1])
+					}
+					port.port, _ = strconv.Atoi(m[2])
+				}
+				if port.member == 0 {
+					slog.Warn("Unable to work out which switch a stack port is on, it's left out of the topology", "ifIndex", it_index, "name", port.name)
 				}
-				ifAdminStatus[it_index] = SnmpIfAdminStatus(v)
+				stackPorts = append(stackPorts, port)
 			}
+			sort.Slice(stackPorts, func(i, j int) bool {
+				if stackPorts[i].member != stackPorts[j].member {
+					return stackPorts[i].member < stackPorts[j].member
+				}
+				return stackPorts[i].port < stackPorts[j].port
+			})
 
-			// get ifOperStatus
-			result, err = common.BulkWalkToMap(&conn, ifOperStatusOID)
+			// get cswRingRedundant, a TruthValue
+			raw, err := common.SnmpGet(&conn, cswRingRedundantOID)
 			if err != nil {
-				slog.Error("BulkWalk of device failed.", "oid", ifOperStatusOID, "error", err)
-				os.Exit(1)
+				slog.Debug("Unable to get ring redundancy, continuing without it", "oid", cswRingRedundantOID, "error", err)
+			} else if v, ok := raw.(int); ok {
+				ringRedundant = v == 1
 			}
-			ifOperStatus := make(map[int]SnmpIfOperStatus)
-			for it_index, it := range result {
-				v, ok := it.(int)
-				if !ok {
-					slog.Warn("Unable to convert value to int", "oid", ifOperStatusOID, "key", it_index, "raw_value", it)
-					continue
+			if !ringRedundant && len(cswSwitchState) > 1 && exitStatus < IcingaWARN {
+				exitStatus = IcingaWARN
+			}
+		} else { // @Assumption: stackwise virtual
+			slog.Debug("Beginning attempt to get stack ports operational statuses on what we're assuming is a stackwise virtual stack", "traditionalStack", traditionalStack)
+
+			if patternForSvlInterfaces != nil {
+				// the user has told us which interfaces are the SVLs
+				ifName, err := common.GetIfNames(&conn)
+				if err != nil {
+					slog.Error("Unable to get interface names.", "error", err)
+					os.Exit(1)
+				}
+				ifAlias, err := common.GetIfAliases(&conn)
+				if err != nil {
+					slog.Error("Unable to get interface aliases.", "error", err)
+					os.Exit(1)
+				}
+
+				// get ifOperStatus
+				result, err = common.BulkWalkToMap(&conn, ifOperStatusOID)
+				if err != nil {
+					slog.Error("BulkWalk of device failed.", "oid", ifOperStatusOID, "error", err)
+					os.Exit(1)
+				}
+				ifOperStatus := make(map[int]SnmpIfOperStatus)
+				for it_index, it := range result {
+					v, ok := it.(int)
+					if !ok {
+						slog.Warn("Unable to convert value to int", "oid", ifOperStatusOID, "key", it_index, "raw_value", it)
+						continue
+					}
+					ifOperStatus[it_index] = SnmpIfOperStatus(v)
+				}
+
+				for it_index, it := range ifName {
+					if patternForSvlInterfaces.MatchString(it) || patternForSvlInterfaces.MatchString(ifAlias[it_index]) {
+						svlInterfaces = append(svlInterfaces, svlInterface{name: it, status: ifOperStatus[it_index]})
+					}
+				}
+			} else {
+				// get cswDistrStackPhyPortOperStatus, the physical ports making up each SVL
+				slog.Debug("Beginning attempt to get SVL ports from CISCO-STACKWISE-MIB", "oid", cswDistrStackPhyPortOperStatusOID)
+				svlResult, err := common.BulkWalkToStringMap(&conn, cswDistrStackPhyPortOperStatusOID)
+				if err != nil {
+					slog.Error("BulkWalk of device failed.", "oid", cswDistrStackPhyPortOperStatusOID, "error", err)
+					os.Exit(1)
+				}
+
+				for it_index, it := range svlResult {
+					v, ok := it.(int)
+					if !ok {
+						slog.Warn("Unable to convert value to int", "oid", cswDistrStackPhyPortOperStatusOID, "key", it_index, "raw_value", it)
+						continue
+					}
+
+					name, err := parseSvlPortName(it_index)
+					if err != nil {
+						slog.Warn("Unable to get the SVL port name from the index", "oid", cswDistrStackPhyPortOperStatusOID, "key", it_index, "error", err)
+						name = it_index
+					}
+
+					// up(1) and down(2), the same values as ifOperStatus
+					svlInterfaces = append(svlInterfaces, svlInterface{name: name, status: SnmpIfOperStatus(v)})
 				}
-				ifOperStatus[it_index] = SnmpIfOperStatus(v)
 			}
+			sort.Slice(svlInterfaces, func(i, j int) bool { return svlInterfaces[i].name < svlInterfaces[j].name })
+			slog.Debug("Identified SVL interfaces", "count", len(svlInterfaces), "fromRegex", patternForSvlInterfaces != nil)
 
-			// check the status of assumed svl interfaces
-			for _, it := range svlInterfaceIDs {
-				if ifAdminStatus[it] != IfAdminStatusUp && ifOperStatus[it] != IfOperStatusUp && exitStatus != IcingaWARN {
+			if len(svlInterfaces) == 0 {
+				exitStatus = IcingaWARN
+			}
+			for _, it := range svlInterfaces {
+				if it.status != IfOperStatusUp && exitStatus < IcingaWARN {
 					exitStatus = IcingaWARN
 				}
-				svlIfStatuses = append(svlIfStatuses, ifOperStatus[it])
 			}
 
 		}
 
+		var problems []string
+		var longOutput strings.Builder
 		var switchStates []SnmpCswSwitchState
-		for _, it := range cswSwitchState {
-			if it != SnmpCswSwitchStateReady && exitStatus != IcingaWARN {
+		nominal := true // every switch Ready and stack port Up, which --state-map can make OK without being true
+		present := 0
+		var master *stackMember
+		for it_index, it := range members {
+			memberStatus := switchStateMap.Status(it.state)
+			if it.state != SnmpCswSwitchStateReady {
+				nominal = false
+			}
+			if memberStatus > exitStatus {
+				exitStatus = memberStatus
+			}
+			if it.state != SnmpCswSwitchStateProvisioned && it.state != SnmpCswSwitchStateRemoved {
+				present += 1
+			}
+			if it.role == SnmpCswSwitchRoleMaster {
+				master = &members[it_index]
+			}
+			switchStates = append(switchStates, it.state)
+
+			longOutput.WriteString(fmt.Sprintf("[%s] Switch %d: %s, %s, priority %d, MAC %s\n", memberStatus, it.number, strings.TrimPrefix(it.role.String(), "SnmpCswSwitchRole"), strings.TrimPrefix(it.state.String(), "SnmpCswSwitchState"), it.priority, it.mac))
+		}
+
+		if traditionalStack {
+			for _, it := range stackPorts {
+				portStatus := stackPortStateMap.Status(it.status)
+				if it.status != SnmpCswStackPortOperStatusUp {
+					nominal = false
+				}
+				neighbour := "nothing connected"
+				if it.neighbour != 0 {
+					neighbour = fmt.Sprintf("neighbour switch %d", it.neighbour)
+				}
+				longOutput.WriteString(fmt.Sprintf("[%s] Switch %d port %d (%s): %s, %s\n", portStatus, it.member, it.port, it.name, strings.TrimPrefix(it.status.String(), "SnmpCswStackPortOperStatus"), neighbour))
+			}
+			if len(members) > 1 {
+				ring := "full"
+				if !ringRedundant {
+					ring = "half"
+					problems = append(problems, "Stack ring is not redundant (half ring)")
+				}
+				longOutput.WriteString(fmt.Sprintf("Stack ring: %s, topology %s\n", ring, stackTopology(stackPorts)))
+			}
+		}
+
+		if expectedMembers > 0 && present < expectedMembers {
+			exitStatus = IcingaCRITICAL
+			problems = append(problems, fmt.Sprintf("%d of %d expected members present", present, expectedMembers))
+		} else if expectedMembers > 0 && present > expectedMembers {
+			if exitStatus < IcingaWARN {
 				exitStatus = IcingaWARN
 			}
-			switchStates = append(switchStates, it)
+			problems = append(problems, fmt.Sprintf("%d members present, expected %d", present, expectedMembers))
+		}
+
+		if master != nil {
+			state, changed, err := updateMasterState(master)
+			if err != nil {
+				slog.Warn("Unable to track master changes.", "file", stateFile, "error", err)
+			} else if changed || (state.PreviousMaster != "" && time.Since(state.ChangedAt) < masterChangeWindow) {
+				if exitStatus < IcingaWARN {
+					exitStatus = IcingaWARN
+				}
+				problems = append(problems, fmt.Sprintf("Master changed from %s to switch %d (%s) at %s", state.PreviousMaster, master.number, master.mac, state.ChangedAt.Format(time.RFC3339)))
+			}
 		}
 
 		// Exit
 		var exitMsg strings.Builder
+		if len(problems) > 0 {
+			exitMsg.WriteString(strings.Join(problems, "; "))
+			exitMsg.WriteString("; ")
+		}
 
-		// @Note: this is not exhaustive, it has an @Assumption that this plug in only ever exits WARN or OK
-		switch exitStatus {
-		case IcingaWARN:
-
+		switch {
+		case exitStatus == IcingaOK && nominal:
+			if traditionalStack {
+				exitMsg.WriteString(fmt.Sprintf("%d switches are \"ready\" and %d stack ports are up", len(switchStates), len(stackPorts)))
+			} else {
+				// @Assumption stackwise virtual
+				exitMsg.WriteString(fmt.Sprintf("%d switches are \"ready\" and %d SVL interfaces are up", len(switchStates), len(svlInterfaces)))
+			}
+		default:
 			exitMsg.WriteString("Switch states are \"")
 			for it_index, it := range switchStates {
 				if it_index > 0 {
@@ -262,40 +521,277 @@ var rootCmd = &cobra.Command{
 				}
 				exitMsg.WriteString(strings.TrimPrefix(it.String(), "SnmpCswSwitchState")) // @Speed
 			}
+			exitMsg.WriteString("\"")
 
 			if traditionalStack {
-				exitMsg.WriteString("\"; Stack port statuses are \"")
-				for it_index, it := range stackPortStatuses {
+				exitMsg.WriteString("; Stack port statuses are \"")
+				for it_index, it := range stackPorts {
 					if it_index > 0 {
 						exitMsg.WriteString(", ")
 					}
-					exitMsg.WriteString(strings.TrimPrefix(it.String(), "SnmpCswStackPortOperState")) // @Speed
+					exitMsg.WriteString(fmt.Sprintf("%s %s", it.name, strings.TrimPrefix(it.status.String(), "SnmpCswStackPortOperStatus"))) // @Speed
 				}
+				exitMsg.WriteString("\"")
+			} else if len(svlInterfaces) == 0 {
+				// @Assumption stackwise virtual
+				exitMsg.WriteString("; No SVL interfaces found (use --svl-interfaces to select them)")
 			} else {
 				// @Assumption stackwise virtual
-				exitMsg.WriteString("\"; SVL interface statuses are \"")
-				for it_index, it := range svlIfStatuses {
+				exitMsg.WriteString("; SVL interface statuses are \"")
+				for it_index, it := range svlInterfaces {
 					if it_index > 0 {
 						exitMsg.WriteString(", ")
 					}
-					exitMsg.WriteString(strings.TrimPrefix(it.String(), "SnmpIfOperStatus")) // @Speed
+					exitMsg.WriteString(fmt.Sprintf("%s %s", it.name, strings.TrimPrefix(it.status.String(), "IfOperStatus"))) // @Speed
 				}
+				exitMsg.WriteString("\"")
 			}
+		}
 
-			exitMsg.WriteString("\"")
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg.String(), LongOutput: longOutput.String()})
 
-		case IcingaOK:
-			if traditionalStack {
-				exitMsg.WriteString(fmt.Sprintf("%d switches are \"ready\" and %d stack ports are up", len(switchStates), len(stackPortStatuses)))
-			} else {
-				// @Assumption stackwise virtual
-				exitMsg.WriteString(fmt.Sprintf("%d switches are \"ready\" and %d SVL interfaces are up", len(switchStates), len(svlIfStatuses)))
+	},
+}
+
+// masterState is what's kept in the state file between runs
+type masterState struct {
+	Master         string    `json:"master"`
+	PreviousMaster string    `json:"previous_master,omitempty"` // set once the master has changed
+	ChangedAt      time.Time `json:"changed_at"`
+}
+
+// updateMasterState records the current master in the state file, along with when it last
+// changed and from what, and returns the updated state and whether it changed on this run. The
+// change is kept until the next one, so a check can keep warning about it for a while.
+func updateMasterState(master *stackMember) (masterState, bool, error) {
+	path := stateFile
+	if path == "" {
+		// somewhere only this user can write, rather than a predictable name in the shared temp directory
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return masterState{}, false, fmt.Errorf("no default state file location (use --state-file): %w", err)
+		}
+		dir := filepath.Join(cacheDir, "go-icingaplugins")
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return masterState{}, false, err
+		}
+		path = filepath.Join(dir, fmt.Sprintf("check_cisco_stackmodules.%s.state", conn.Target))
+	}
+
+	var state masterState
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return state, false, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil && len(data) > 0 {
+		// older versions only wrote the MAC address of the master
+		state = masterState{Master: strings.TrimSpace(string(data))}
+	}
+
+	changed := state.Master != "" && state.Master != master.mac
+	if changed {
+		state.PreviousMaster = state.Master
+		state.ChangedAt = time.Now()
+	}
+	state.Master = master.mac
+
+	data, err = json.Marshal(state)
+	if err != nil {
+		return state, changed, err
+	}
+	// write a new file and rename it over the old one, so a symlink at path is replaced rather
+	// than followed, and a run that dies part way through doesn't leave a truncated file
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return state, changed, err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return state, changed, err
+	}
+
+	return state, changed, nil
+}
+
+// stackPortSwitchNumbers maps the ifIndex of each interface to the number of the switch it's
+// on, by following entAliasMappingIdentifier to the port's entity and entPhysicalContainedIn up
+// to the chassis, which is what cswSwitchInfoTable is indexed by
+func stackPortSwitchNumbers(conn *g.GoSNMP, switchNumberByEntity map[int]int) (map[int]int, error) {
+	result, err := common.BulkWalkToStringMap(conn, entAliasMappingIdentifierOID)
+	if err != nil {
+		return nil, fmt.Errorf("BulkWalk of %s failed: %w", entAliasMappingIdentifierOID, err)
+	}
+	entityByIfIndex := make(map[int]int)
+	for it_index, it := range result {
+		v, ok := it.(string)
+		if !ok {
+			slog.Warn("Unable to convert value to string", "oid", entAliasMappingIdentifierOID, "key", it_index, "raw_value", it)
+			continue
+		}
+		ifIndexPart, found := strings.CutPrefix(strings.TrimPrefix(v, "."), ifIndexOID+".")
+		if !found {
+			continue
+		}
+		ifIndex, err := strconv.Atoi(ifIndexPart)
+		if err != nil {
+			slog.Warn("Unable to parse ifIndex", "oid", entAliasMappingIdentifierOID, "key", it_index, "raw_value", v)
+			continue
+		}
+		entPart, _, _ := strings.Cut(it_index, ".")
+		entIndex, err := strconv.Atoi(entPart)
+		if err != nil {
+			slog.Warn("Unable to parse entity index", "oid", entAliasMappingIdentifierOID, "key", it_index)
+			continue
+		}
+		entityByIfIndex[ifIndex] = entIndex
+	}
+
+	entityColumns := make(map[string]map[int]int)
+	for _, oid := range []string{entPhysicalContainedInOID, entPhysicalClassOID} {
+		result, err := common.BulkWalkToMap(conn, oid)
+		if err != nil {
+			return nil, fmt.Errorf("BulkWalk of %s failed: %w", oid, err)
+		}
+		entityColumns[oid] = make(map[int]int)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", oid, "key", it_index, "raw_value", it)
+				continue
 			}
+			entityColumns[oid][it_index] = v
 		}
+	}
 
-		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg.String()})
+	switchNumberByIfIndex := make(map[int]int)
+	for ifIndex, it := range entityByIfIndex {
+		chassis := it
+		for depth := 0; depth < maxContainmentDepth && chassis != 0 && SnmpIanaPhysicalClass(entityColumns[entPhysicalClassOID][chassis]) != IanaPhysicalClassChassis; depth++ {
+			chassis = entityColumns[entPhysicalContainedInOID][chassis]
+		}
+		if n, ok := switchNumberByEntity[chassis]; ok {
+			switchNumberByIfIndex[ifIndex] = n
+		}
+	}
 
-	},
+	return switchNumberByIfIndex, nil
+}
+
+// parseSvlPortName gets cswDistrStackPhyPort from a cswDistrStackPhyPortInfoTable index,
+// entPhysicalIndex.cswDistrStackLinkBundleIdentifier.length.characters...
+func parseSvlPortName(index string) (string, error) {
+	parts := strings.Split(index, ".")
+	if len(parts) < 3 {
+		return "", fmt.Errorf("index too short: %s", index)
+	}
+
+	length, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("unable to parse port name length: %w", err)
+	}
+	if len(parts) != 3+length {
+		return "", fmt.Errorf("index doesn't match port name length %d: %s", length, index)
+	}
+
+	name := make([]byte, length)
+	for it_index := range name {
+		c, err := strconv.Atoi(parts[3+it_index])
+		if err != nil || c < 0 || c > 255 {
+			return "", fmt.Errorf("invalid port name character in index: %s", index)
+		}
+		name[it_index] = byte(c)
+	}
+
+	return string(name), nil
+}
+
+// defaultSwitchStateStatus is used for switch states not given with --state-map
+func defaultSwitchStateStatus(state SnmpCswSwitchState) IcingaStatusVal {
+	if state == SnmpCswSwitchStateReady {
+		return IcingaOK
+	}
+	return IcingaWARN
+}
+
+// defaultStackPortStatus is used for stack port states not given with --state-map
+func defaultStackPortStatus(state SnmpCswStackPortOperStatus) IcingaStatusVal {
+	if state == SnmpCswStackPortOperStatusUp {
+		return IcingaOK
+	}
+	return IcingaWARN
+}
+
+// stackTopology follows the stack cables from the lowest numbered switch, eg.
+// "1→2→3→1" for a full ring or "1→2→3" when the ring is broken.
+func stackTopology(ports []stackPort) string {
+	links := make(map[int]map[int]int) // member -> neighbour -> number of up cables
+	for _, it := range ports {
+		if it.status != SnmpCswStackPortOperStatusUp || it.neighbour == 0 || it.member == 0 {
+			continue
+		}
+		if links[it.member] == nil {
+			links[it.member] = make(map[int]int)
+		}
+		links[it.member][it.neighbour] += 1
+	}
+	if len(links) == 0 {
+		return "none"
+	}
+
+	var start int
+	for it := range links {
+		if start == 0 || it < start {
+			start = it
+		}
+	}
+
+	path := []string{fmt.Sprint(start)}
+	visited := map[int]bool{start: true}
+	previous, current := 0, start
+	for {
+		var neighbours []int
+		for it := range links[current] {
+			neighbours = append(neighbours, it)
+		}
+		sort.Ints(neighbours)
+
+		next := 0
+		for _, it := range neighbours {
+			// don't go back the way we came, unless it's a two member ring with both cables up
+			if it == previous && !(it == start && links[current][it] > 1) {
+				continue
+			}
+			next = it
+			break
+		}
+		if next == 0 {
+			break
+		}
+
+		path = append(path, fmt.Sprint(next))
+		if visited[next] {
+			break
+		}
+		visited[next] = true
+		previous, current = current, next
+	}
+
+	return strings.Join(path, "→")
+}
+
+func formatMacAddress(raw string) string {
+	var parts []string
+	for _, b := range []byte(raw) {
+		parts = append(parts, fmt.Sprintf("%02x", b))
+	}
+	return strings.Join(parts, ":")
 }
 
 func init() {
@@ -319,6 +815,11 @@ func init() {
 	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
 
 	rootCmd.PersistentFlags().Uint8Var(&maxTimesToRetryModelQuery, "max-model-query-retries", 2, "How many times to retry the initial query for model (at half second intervals)")
+	rootCmd.PersistentFlags().IntVar(&expectedMembers, "expected-members", 0, "Number of switches expected in the stack, critical if fewer are present (default not checked)")
+	rootCmd.PersistentFlags().StringVar(&stateFile, "state-file", "", "File used to remember the master between runs (default in the user's cache directory, eg. ~/.cache/go-icingaplugins)")
+	rootCmd.PersistentFlags().DurationVar(&masterChangeWindow, "master-change-window", time.Hour, "How long to keep warning after the master changes, eg. 30m or 24h (0 warns on the first run after the change only)")
+	rootCmd.PersistentFlags().Var(&stateMapFlag, "state-map", "Override the Icinga state of switch or stack port states, eg. Removed=critical,Provisioned=ok (default anything not Ready or Up is warn)")
+	rootCmd.PersistentFlags().StringVar(&svlInterfacesFilter, "svl-interfaces", "", "Regex matched against ifName and ifAlias to select the SVL interfaces, instead of asking the device")
 }
 
 func Execute() {
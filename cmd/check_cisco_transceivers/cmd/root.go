@@ -0,0 +1,611 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var interfaceFilter string
+
+// ENTITY-MIB
+const entPhysicalContainedInOID string = "1.3.6.1.2.1.47.1.1.1.1.4"
+const entPhysicalClassOID string = "1.3.6.1.2.1.47.1.1.1.1.5"
+const entPhysicalNameOID string = "1.3.6.1.2.1.47.1.1.1.1.7"
+const entAliasMappingIdentifierOID string = "1.3.6.1.2.1.47.1.3.2.1.2" // index is entPhysicalIndex.entAliasLogicalIndexOrZero
+
+// CISCO-ENTITY-SENSOR-MIB
+const entSensorTypeOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.1"
+const entSensorScaleOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.2"
+const entSensorPrecisionOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.3"
+const entSensorValueOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.4"
+const entSensorStatusOID string = "1.3.6.1.4.1.9.9.91.1.1.1.1.5"
+const entSensorThresholdSeverityOID string = "1.3.6.1.4.1.9.9.91.1.2.1.1.2" // index is entPhysicalIndex.entSensorThresholdIndex
+const entSensorThresholdRelationOID string = "1.3.6.1.4.1.9.9.91.1.2.1.1.3"
+const entSensorThresholdValueOID string = "1.3.6.1.4.1.9.9.91.1.2.1.1.4"
+
+// IF-MIB
+const ifIndexOID string = "1.3.6.1.2.1.2.2.1.1"
+
+// how far up the containment tree we will go looking for the port a sensor belongs to
+const maxContainmentDepth = 5
+
+type sensorThreshold struct {
+	severity SnmpEntSensorThresholdSeverity
+	relation SnmpEntSensorThresholdRelation
+	value    int
+}
+
+type transceiverSensor struct {
+	entIndex  int
+	name      string
+	kind      string
+	unit      string
+	value     float64
+	status    SnmpEntSensorStatus
+	state     IcingaStatusVal
+	warnRange string
+	critRange string
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_transceivers",
+	Short: "Cisco optical transceiver (DOM) check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		ctx := context.Background() // just here so we can log using slog.Log() with common.LevelTrace
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		var patternForInterface *regexp.Regexp
+		if interfaceFilter != "" {
+			var err error
+			patternForInterface, err = regexp.Compile(interfaceFilter)
+			if err != nil {
+				slog.Error("Unable to compile interface filter.", "pattern", interfaceFilter, "error", err)
+				os.Exit(1)
+			}
+		}
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// get entPhysicalClass
+		result, err := common.BulkWalkToMap(&conn, entPhysicalClassOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entPhysicalClassOID, "error", err)
+			os.Exit(1)
+		}
+		entPhysicalClass := make(map[int]SnmpIanaPhysicalClass)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entPhysicalClassOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entPhysicalClass[it_index] = SnmpIanaPhysicalClass(v)
+		}
+
+		// get entPhysicalContainedIn
+		result, err = common.BulkWalkToMap(&conn, entPhysicalContainedInOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entPhysicalContainedInOID, "error", err)
+			os.Exit(1)
+		}
+		entPhysicalContainedIn := make(map[int]int)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entPhysicalContainedInOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entPhysicalContainedIn[it_index] = v
+		}
+
+		// get entPhysicalName
+		result, err = common.BulkWalkToMap(&conn, entPhysicalNameOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entPhysicalNameOID, "error", err)
+			os.Exit(1)
+		}
+		entPhysicalName := make(map[int]string)
+		for it_index, it := range result {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", entPhysicalNameOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entPhysicalName[it_index] = v
+		}
+
+		// get entAliasMappingIdentifier, the values are OIDs like ifIndex.<n>
+		result2, err := common.BulkWalkToStringMap(&conn, entAliasMappingIdentifierOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entAliasMappingIdentifierOID, "error", err)
+			os.Exit(1)
+		}
+		entityToIfIndex := make(map[int]int)
+		for it_index, it := range result2 {
+			v, ok := it.(string)
+			if !ok {
+				slog.Warn("Unable to convert value to string", "oid", entAliasMappingIdentifierOID, "key", it_index, "raw_value", it)
+				continue
+			}
+
+			entPart, _, _ := strings.Cut(it_index, ".")
+			entIndex, err := strconv.Atoi(entPart)
+			if err != nil {
+				slog.Warn("Unable to parse entity index", "oid", entAliasMappingIdentifierOID, "key", it_index)
+				continue
+			}
+
+			ifIndexPart, found := strings.CutPrefix(strings.TrimPrefix(v, "."), ifIndexOID+".")
+			if !found {
+				slog.Debug("Alias mapping does not point at an ifIndex, skipping", "key", it_index, "raw_value", v)
+				continue
+			}
+			ifIndex, err := strconv.Atoi(ifIndexPart)
+			if err != nil {
+				slog.Warn("Unable to parse ifIndex", "oid", entAliasMappingIdentifierOID, "key", it_index, "raw_value", v)
+				continue
+			}
+			entityToIfIndex[entIndex] = ifIndex
+		}
+
+		// get ifName
+		ifName, err := common.GetIfNames(&conn)
+		if err != nil {
+			slog.Error("Unable to get interface names.", "error", err)
+			os.Exit(1)
+		}
+
+		// get entSensorType
+		result, err = common.BulkWalkToMap(&conn, entSensorTypeOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorTypeOID, "error", err)
+			os.Exit(1)
+		}
+		entSensorType := make(map[int]SnmpEntSensorDataType)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorTypeOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entSensorType[it_index] = SnmpEntSensorDataType(v)
+		}
+
+		// Work out which sensors belong to a port, and what interface name that port has.
+		sensorInterface := make(map[int]string)
+		for it_index, it := range entSensorType {
+			l := slog.With("entPhysicalIndex", it_index, "sensorType", it)
+			switch it {
+			case EntSensorDataTypeDBm, EntSensorDataTypeCelsius, EntSensorDataTypeAmperes:
+			default:
+				l.Log(ctx, common.LevelTrace, "Sensor type is not interesting, skipping")
+				continue
+			}
+
+			parent := entPhysicalContainedIn[it_index]
+			for depth := 0; depth < maxContainmentDepth && parent != 0; depth++ {
+				if ifIndex, ok := entityToIfIndex[parent]; ok {
+					if name, ok := ifName[ifIndex]; ok {
+						sensorInterface[it_index] = name
+						break
+					}
+				}
+				if entPhysicalClass[parent] == IanaPhysicalClassPort {
+					sensorInterface[it_index] = entPhysicalName[parent]
+					break
+				}
+				parent = entPhysicalContainedIn[parent]
+			}
+
+			if _, ok := sensorInterface[it_index]; !ok {
+				l.Log(ctx, common.LevelTrace, "Sensor is not contained by a port, skipping")
+				continue
+			}
+
+			if patternForInterface != nil && !patternForInterface.MatchString(sensorInterface[it_index]) {
+				l.Debug("Interface does not match filter, skipping", "interface", sensorInterface[it_index], "pattern", interfaceFilter)
+				delete(sensorInterface, it_index)
+			}
+		}
+		slog.Debug("Finished discovering transceiver sensors", "count", len(sensorInterface))
+
+		if len(sensorInterface) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "No transceiver sensors found."})
+		}
+
+		// get entSensorScale
+		result, err = common.BulkWalkToMap(&conn, entSensorScaleOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorScaleOID, "error", err)
+			os.Exit(1)
+		}
+		entSensorScale := make(map[int]SnmpEntSensorDataScale)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorScaleOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entSensorScale[it_index] = SnmpEntSensorDataScale(v)
+		}
+
+		// get entSensorPrecision
+		result, err = common.BulkWalkToMap(&conn, entSensorPrecisionOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorPrecisionOID, "error", err)
+			os.Exit(1)
+		}
+		entSensorPrecision := make(map[int]int)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorPrecisionOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entSensorPrecision[it_index] = v
+		}
+
+		// get entSensorValue
+		result, err = common.BulkWalkToMap(&conn, entSensorValueOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorValueOID, "error", err)
+			os.Exit(1)
+		}
+		entSensorValue := make(map[int]int)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorValueOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entSensorValue[it_index] = v
+		}
+
+		// get entSensorStatus
+		result, err = common.BulkWalkToMap(&conn, entSensorStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorStatusOID, "error", err)
+			os.Exit(1)
+		}
+		entSensorStatus := make(map[int]SnmpEntSensorStatus)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			entSensorStatus[it_index] = SnmpEntSensorStatus(v)
+		}
+
+		// get the threshold table, keyed by entPhysicalIndex.entSensorThresholdIndex
+		result2, err = common.BulkWalkToStringMap(&conn, entSensorThresholdSeverityOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorThresholdSeverityOID, "error", err)
+			os.Exit(1)
+		}
+		thresholds := make(map[string]*sensorThreshold)
+		for it_index, it := range result2 {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorThresholdSeverityOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			thresholds[it_index] = &sensorThreshold{severity: SnmpEntSensorThresholdSeverity(v)}
+		}
+
+		result2, err = common.BulkWalkToStringMap(&conn, entSensorThresholdRelationOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorThresholdRelationOID, "error", err)
+			os.Exit(1)
+		}
+		for it_index, it := range result2 {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorThresholdRelationOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			if t, ok := thresholds[it_index]; ok {
+				t.relation = SnmpEntSensorThresholdRelation(v)
+			}
+		}
+
+		result2, err = common.BulkWalkToStringMap(&conn, entSensorThresholdValueOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", entSensorThresholdValueOID, "error", err)
+			os.Exit(1)
+		}
+		for it_index, it := range result2 {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", entSensorThresholdValueOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			if t, ok := thresholds[it_index]; ok {
+				t.value = v
+			}
+		}
+
+		sensorThresholds := make(map[int][]*sensorThreshold)
+		for it_index, it := range thresholds {
+			entPart, _, _ := strings.Cut(it_index, ".")
+			entIndex, err := strconv.Atoi(entPart)
+			if err != nil {
+				slog.Warn("Unable to parse entity index", "oid", entSensorThresholdSeverityOID, "key", it_index)
+				continue
+			}
+			if _, ok := sensorInterface[entIndex]; !ok {
+				continue
+			}
+			sensorThresholds[entIndex] = append(sensorThresholds[entIndex], it)
+		}
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var perfData strings.Builder
+		var longOutput strings.Builder
+
+		var sensors []*transceiverSensor
+		for it_index, it := range sensorInterface {
+			sensor := &transceiverSensor{
+				entIndex: it_index,
+				name:     it,
+				status:   entSensorStatus[it_index],
+				state:    IcingaOK,
+			}
+
+			scale := entSensorScale[it_index]
+			precision := entSensorPrecision[it_index]
+			raw := entSensorValue[it_index]
+
+			switch entSensorType[it_index] {
+			case EntSensorDataTypeCelsius:
+				sensor.kind = "temperature"
+				sensor.unit = "C"
+			case EntSensorDataTypeAmperes:
+				// bias current is more readable in mA
+				sensor.kind = "bias"
+				sensor.unit = "mA"
+				scale += 1
+			case EntSensorDataTypeDBm:
+				sensorName := strings.ToLower(entPhysicalName[it_index])
+				switch {
+				case strings.Contains(sensorName, "receive") || strings.Contains(sensorName, "rx"):
+					sensor.kind = "rx_power"
+				case strings.Contains(sensorName, "transmit") || strings.Contains(sensorName, "tx"):
+					sensor.kind = "tx_power"
+				default:
+					sensor.kind = "power"
+				}
+				sensor.unit = "dBm"
+			}
+			sensor.value = scaleSensorValue(raw, scale, precision)
+
+			var warnLow, warnHigh, critLow, critHigh string
+			for _, t := range sensorThresholds[it_index] {
+				if t.relation == EntSensorThresholdRelationEqualTo || t.relation == EntSensorThresholdRelationNotEqualTo {
+					continue
+				}
+
+				isLow := t.relation == EntSensorThresholdRelationLessThan || t.relation == EntSensorThresholdRelationLessOrEqual
+				boundary := strconv.FormatFloat(scaleSensorValue(t.value, scale, precision), 'f', -1, 64)
+
+				var severity IcingaStatusVal
+				switch t.severity {
+				case EntSensorThresholdSeverityMinor:
+					severity = IcingaWARN
+					if isLow {
+						warnLow = boundary
+					} else {
+						warnHigh = boundary
+					}
+				case EntSensorThresholdSeverityMajor, EntSensorThresholdSeverityCritical:
+					severity = IcingaCRITICAL
+					if isLow {
+						critLow = boundary
+					} else {
+						critHigh = boundary
+					}
+				default:
+					continue
+				}
+
+				if sensor.status == EntSensorStatusOk && thresholdBreached(raw, t) && sensor.state < severity {
+					sensor.state = severity
+				}
+			}
+			sensor.warnRange = icingaRange(warnLow, warnHigh)
+			sensor.critRange = icingaRange(critLow, critHigh)
+
+			if sensor.status == EntSensorStatusNonOperational && sensor.state < IcingaWARN {
+				sensor.state = IcingaWARN
+			}
+
+			sensors = append(sensors, sensor)
+		}
+
+		sort.Slice(sensors, func(i, j int) bool {
+			if sensors[i].name != sensors[j].name {
+				return sensors[i].name < sensors[j].name
+			}
+			if sensors[i].kind != sensors[j].kind {
+				return sensors[i].kind < sensors[j].kind
+			}
+			return sensors[i].entIndex < sensors[j].entIndex
+		})
+
+		// multi-lane optics (eg. QSFP) have a sensor per lane, so make sure the labels stay unique
+		seenLabels := make(map[string]bool)
+		for _, it := range sensors {
+			label := it.kind
+			if seenLabels[it.name+label] {
+				label = fmt.Sprintf("%s_%d", it.kind, it.entIndex)
+			}
+			seenLabels[it.name+label] = true
+			it.kind = label
+		}
+
+		interfaces := make(map[string]bool)
+		problemInterfaces := make(map[string]bool)
+		for _, it := range sensors {
+			interfaces[it.name] = true
+
+			if it.status != EntSensorStatusOk {
+				longOutput.WriteString(fmt.Sprintf("[%s] %s %s: %s\n", it.state, it.name, it.kind, strings.TrimPrefix(it.status.String(), "EntSensorStatus")))
+				if it.state != IcingaOK {
+					problemInterfaces[it.name] = true
+				}
+				if exitStatus < it.state {
+					exitStatus = it.state
+				}
+				continue
+			}
+
+			perfData.WriteString(fmt.Sprintf("'%s_%s'=%v%s;%s;%s;; ", it.name, it.kind, it.value, perfUnit(it.unit), it.warnRange, it.critRange))
+			longOutput.WriteString(fmt.Sprintf("[%s] %s %s: %v%s\n", it.state, it.name, it.kind, it.value, it.unit))
+
+			if it.state != IcingaOK {
+				problemInterfaces[it.name] = true
+			}
+			if exitStatus < it.state {
+				exitStatus = it.state
+			}
+		}
+
+		var exitMsg string
+		if len(problemInterfaces) == 0 {
+			exitMsg = fmt.Sprintf("All %d transceiver sensors on %d interfaces are within thresholds", len(sensors), len(interfaces))
+		} else {
+			var names []string
+			for it := range problemInterfaces {
+				names = append(names, it)
+			}
+			sort.Strings(names)
+			exitMsg = fmt.Sprintf("%d of %d interfaces have transceiver sensors outside thresholds: %s", len(problemInterfaces), len(interfaces), strings.Join(names, ", "))
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
+	},
+}
+
+// scaleSensorValue turns a raw entSensorValue into a float in the sensors base unit, eg. a value
+// of -23 with a precision of 1 becomes -2.3
+func scaleSensorValue(raw int, scale SnmpEntSensorDataScale, precision int) float64 {
+	exponent := 3*(int(scale)-int(EntSensorDataScaleUnits)) - precision
+	return math.Round(float64(raw)*math.Pow10(exponent)*1000) / 1000
+}
+
+func thresholdBreached(value int, t *sensorThreshold) bool {
+	switch t.relation {
+	case EntSensorThresholdRelationLessThan:
+		return value < t.value
+	case EntSensorThresholdRelationLessOrEqual:
+		return value <= t.value
+	case EntSensorThresholdRelationGreaterThan:
+		return value > t.value
+	case EntSensorThresholdRelationGreaterOrEqual:
+		return value >= t.value
+	case EntSensorThresholdRelationEqualTo:
+		return value == t.value
+	case EntSensorThresholdRelationNotEqualTo:
+		return value != t.value
+	default:
+		return false
+	}
+}
+
+// icingaRange builds a perfdata range (low:high) from the device thresholds, either side may be missing.
+// A bare high value would mean 0:high, which is wrong for dBm, so the low side is ~ (no limit) instead.
+func icingaRange(low string, high string) string {
+	switch {
+	case low == "" && high == "":
+		return ""
+	case low == "":
+		return "~:" + high
+	case high == "":
+		return low + ":"
+	default:
+		return low + ":" + high
+	}
+}
+
+// icinga doesn't know about dBm, so it's emitted without a unit
+func perfUnit(unit string) string {
+	if unit == "dBm" {
+		return ""
+	}
+	return unit
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().StringVar(&interfaceFilter, "interfaces", "", "Only check transceivers on interfaces matching this regex (eg. '^Te1/1/')")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var minUptime time.Duration
+
+const sysUpTimeOID string = "1.3.6.1.2.1.1.3.0"           // returns timeticks, wraps after ~497 days
+const snmpEngineTimeOID string = "1.3.6.1.6.3.10.2.1.3.0" // returns seconds
+
+// OLD-CISCO-CHASSIS-MIB. Devices without it (eg. IOS-XE and NX-OS) don't expose the reload
+// reason anywhere else we know of, so the check says it isn't available.
+const whyReloadOID string = "1.3.6.1.4.1.9.2.1.2.0"
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_uptime",
+	Short: "Cisco device uptime and reload reason check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		raw, err := common.SnmpGet(&conn, sysUpTimeOID)
+		if err != nil {
+			slog.Error("Error while attempting to get sysUpTime.", "oid", sysUpTimeOID, "error", err)
+			os.Exit(1)
+		}
+		sysUpTime, ok := raw.(uint32)
+		if !ok {
+			slog.Error("Unable to convert value to uint32", "oid", sysUpTimeOID, "raw_value", raw)
+			os.Exit(1)
+		}
+		uptime := time.Duration(sysUpTime) * 10 * time.Millisecond // hundredths of a second
+
+		// sysUpTime wraps back to zero after ~497 days, snmpEngineTime (in
+		// seconds) doesn't. If the engine has been up longer than sysUpTime
+		// says, sysUpTime has wrapped and we trust the engine instead.
+		raw, err = common.SnmpGet(&conn, snmpEngineTimeOID)
+		if err != nil {
+			slog.Debug("Unable to get snmpEngineTime, continuing with sysUpTime only", "oid", snmpEngineTimeOID, "error", err)
+		} else if v, ok := raw.(int); ok {
+			engineTime := time.Duration(v) * time.Second
+			if engineTime > uptime+time.Minute {
+				slog.Debug("sysUpTime appears to have wrapped, using snmpEngineTime", "sysUpTime", uptime, "snmpEngineTime", engineTime)
+				uptime = engineTime
+			}
+		}
+
+		reloadReason := ""
+		raw, err = common.SnmpGet(&conn, whyReloadOID)
+		if err != nil {
+			slog.Debug("Unable to get reload reason, continuing without it", "oid", whyReloadOID, "error", err)
+		} else if v, ok := raw.(string); ok {
+			reloadReason = strings.TrimSpace(v)
+		}
+		slog.Debug("Collected uptime", "uptime", uptime, "reloadReason", reloadReason)
+
+		var exitStatus IcingaStatusVal = IcingaOK
+		var exitMsg strings.Builder
+
+		if uptime < minUptime {
+			exitStatus = IcingaWARN
+			exitMsg.WriteString(fmt.Sprintf("Device reloaded %s ago", formatUptime(uptime)))
+		} else {
+			exitMsg.WriteString(fmt.Sprintf("Uptime is %s", formatUptime(uptime)))
+		}
+		if reloadReason != "" {
+			exitMsg.WriteString(fmt.Sprintf(" (last reload reason: %s)", reloadReason))
+		} else {
+			exitMsg.WriteString(" (no reload reason available)")
+		}
+
+		perfData := fmt.Sprintf("'uptime'=%.0fs;%.0f:;;0;", uptime.Seconds(), minUptime.Seconds())
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg.String(), PerfData: perfData})
+
+	},
+}
+
+// formatUptime renders a duration the way `show version` does, eg. "12d 3h 4m"
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	}
+	return fmt.Sprintf("%dh %dm", hours, minutes)
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().DurationVarP(&minUptime, "min-uptime", "w", time.Hour, "Warn if the device has been up for less than this (eg. 30m, 24h)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
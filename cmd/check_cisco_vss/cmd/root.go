@@ -0,0 +1,312 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+
+	"github.com/spf13/cobra"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var expectedActive int
+
+const ifOperStatusOID string = "1.3.6.1.2.1.2.2.1.8"
+
+// CISCO-VIRTUAL-SWITCH-MIB
+const cvsDomainOID string = "1.3.6.1.4.1.9.9.388.1.1.1.0"
+const cvsSwitchModeOID string = "1.3.6.1.4.1.9.9.388.1.1.4.0"
+
+// CISCO-VIRTUAL-SWITCH-MIB, cvsChassisTable indexed by cvsChassisSwitchID
+const cvsChassisRoleOID string = "1.3.6.1.4.1.9.9.388.1.2.2.1.2"
+
+// CISCO-VIRTUAL-SWITCH-MIB, cvsVSLConnectionTable indexed by cvsVSLConnectionId
+const cvsVSLConnectOperStatusOID string = "1.3.6.1.4.1.9.9.388.1.3.1.1.3"
+const cvsVSLConfiguredPortCountOID string = "1.3.6.1.4.1.9.9.388.1.3.1.1.5"
+const cvsVSLOperationalPortCountOID string = "1.3.6.1.4.1.9.9.388.1.3.1.1.6"
+
+// CISCO-VIRTUAL-SWITCH-MIB, cvsVSLPortStatsTable indexed by ifIndex
+// only used to find which interfaces are VSL ports, so we walk the whole entry
+const cvsVSLPortStatsEntryOID string = "1.3.6.1.4.1.9.9.388.1.3.2.1"
+
+var rootCmd = &cobra.Command{
+	Use:   "check_cisco_vss",
+	Short: "Cisco virtual switch (VSS) domain check plugin",
+	Long:  "",
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err := conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		raw, err := common.SnmpGet(&conn, cvsSwitchModeOID)
+		if err != nil {
+			if err.Error() == "SNMP Response: No Such Object" {
+				common.ExitPlugin(&IcingaStatus{Value: IcingaUNKNOWN, Message: "Device does not support CISCO-VIRTUAL-SWITCH-MIB"})
+			}
+			slog.Error("Error while attempting to get switch mode.", "oid", cvsSwitchModeOID, "error", err)
+			os.Exit(1)
+		}
+		switchMode := SnmpCvsSwitchMode(raw.(int)) // @Assumption
+		if switchMode != CvsSwitchModeMultiNode {
+			common.ExitPlugin(&IcingaStatus{Value: IcingaCRITICAL, Message: fmt.Sprintf("Device is not in virtual switch mode (%s)", strings.TrimPrefix(switchMode.String(), "CvsSwitchMode"))})
+		}
+
+		domain := 0
+		raw, err = common.SnmpGet(&conn, cvsDomainOID)
+		if err != nil {
+			slog.Debug("Unable to get virtual switch domain, continuing without it", "oid", cvsDomainOID, "error", err)
+		} else if v, ok := raw.(int); ok {
+			domain = v
+		}
+
+		// get cvsChassisRole
+		result, err := common.BulkWalkToMap(&conn, cvsChassisRoleOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cvsChassisRoleOID, "error", err)
+			os.Exit(1)
+		}
+		cvsChassisRole := make(map[int]SnmpCvsChassisRole)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cvsChassisRoleOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cvsChassisRole[it_index] = SnmpCvsChassisRole(v)
+		}
+
+		// get cvsVSLConnectOperStatus
+		result, err = common.BulkWalkToMap(&conn, cvsVSLConnectOperStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cvsVSLConnectOperStatusOID, "error", err)
+			os.Exit(1)
+		}
+		cvsVSLConnectOperStatus := make(map[int]SnmpCvsVSLConnectOperStatus)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", cvsVSLConnectOperStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			cvsVSLConnectOperStatus[it_index] = SnmpCvsVSLConnectOperStatus(v)
+		}
+
+		// get cvsVSLConfiguredPortCount and cvsVSLOperationalPortCount
+		cvsVSLConfiguredPortCount := make(map[int]int)
+		cvsVSLOperationalPortCount := make(map[int]int)
+		for oid, dest := range map[string]map[int]int{cvsVSLConfiguredPortCountOID: cvsVSLConfiguredPortCount, cvsVSLOperationalPortCountOID: cvsVSLOperationalPortCount} {
+			result, err = common.BulkWalkToMap(&conn, oid)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", oid, "error", err)
+				os.Exit(1)
+			}
+			for it_index, it := range result {
+				v, ok := it.(int)
+				if !ok {
+					slog.Warn("Unable to convert value to int", "oid", oid, "key", it_index, "raw_value", it)
+					continue
+				}
+				dest[it_index] = v
+			}
+		}
+
+		// find the VSL ports
+		statsResult, err := common.BulkWalkToStringMap(&conn, cvsVSLPortStatsEntryOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", cvsVSLPortStatsEntryOID, "error", err)
+			os.Exit(1)
+		}
+		vslPortSet := make(map[int]bool)
+		for it_index := range statsResult {
+			_, ifIndexPart, _ := strings.Cut(it_index, ".")
+			ifIndex, err := strconv.Atoi(ifIndexPart)
+			if err != nil {
+				slog.Warn("Unable to parse ifIndex from index", "oid", cvsVSLPortStatsEntryOID, "key", it_index)
+				continue
+			}
+			vslPortSet[ifIndex] = true
+		}
+		var vslPorts []int
+		for it := range vslPortSet {
+			vslPorts = append(vslPorts, it)
+		}
+		sort.Ints(vslPorts)
+
+		// get ifOperStatus
+		result, err = common.BulkWalkToMap(&conn, ifOperStatusOID)
+		if err != nil {
+			slog.Error("BulkWalk of device failed.", "oid", ifOperStatusOID, "error", err)
+			os.Exit(1)
+		}
+		ifOperStatus := make(map[int]SnmpIfOperStatus)
+		for it_index, it := range result {
+			v, ok := it.(int)
+			if !ok {
+				slog.Warn("Unable to convert value to int", "oid", ifOperStatusOID, "key", it_index, "raw_value", it)
+				continue
+			}
+			ifOperStatus[it_index] = SnmpIfOperStatus(v)
+		}
+
+		ifName, err := common.GetIfNames(&conn)
+		if err != nil {
+			slog.Error("Problem when attempting to get interface names.", "error", err)
+			os.Exit(1)
+		}
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var problems []string
+		var longOutput strings.Builder
+
+		// chassis roles
+		var chassis []int
+		for it_index := range cvsChassisRole {
+			chassis = append(chassis, it_index)
+		}
+		sort.Ints(chassis)
+
+		activeCount, standbyCount, activeSwitch := 0, 0, 0
+		for _, it := range chassis {
+			role := cvsChassisRole[it]
+			switch role {
+			case CvsChassisRoleActive:
+				activeCount += 1
+				activeSwitch = it
+			case CvsChassisRoleStandby:
+				standbyCount += 1
+			}
+			longOutput.WriteString(fmt.Sprintf("Switch %d: %s\n", it, strings.TrimPrefix(role.String(), "CvsChassisRole")))
+		}
+
+		if len(chassis) < 2 {
+			exitStatus = IcingaCRITICAL
+			problems = append(problems, fmt.Sprintf("only %d chassis present", len(chassis)))
+		} else if activeCount != 1 || standbyCount != 1 {
+			exitStatus = IcingaCRITICAL
+			problems = append(problems, fmt.Sprintf("%d active and %d standby chassis, expected one of each", activeCount, standbyCount))
+		}
+		if expectedActive != 0 && activeCount == 1 && activeSwitch != expectedActive {
+			if exitStatus < IcingaWARN {
+				exitStatus = IcingaWARN
+			}
+			problems = append(problems, fmt.Sprintf("switch %d is active, expected switch %d", activeSwitch, expectedActive))
+		}
+
+		// VSL connections
+		var connections []int
+		for it_index := range cvsVSLConnectOperStatus {
+			connections = append(connections, it_index)
+		}
+		sort.Ints(connections)
+
+		if len(connections) == 0 {
+			exitStatus = IcingaCRITICAL
+			problems = append(problems, "no VSL connections")
+		}
+		for _, it := range connections {
+			connStatus := IcingaOK
+			configured := cvsVSLConfiguredPortCount[it]
+			operational := cvsVSLOperationalPortCount[it]
+			if cvsVSLConnectOperStatus[it] != CvsVSLConnectOperStatusUp {
+				connStatus = IcingaCRITICAL
+				problems = append(problems, fmt.Sprintf("VSL %d is down", it))
+			} else if operational < configured {
+				connStatus = IcingaWARN
+				problems = append(problems, fmt.Sprintf("VSL %d has %d of %d ports up", it, operational, configured))
+			}
+			if connStatus > exitStatus {
+				exitStatus = connStatus
+			}
+			longOutput.WriteString(fmt.Sprintf("[%s] VSL %d: %s, %d of %d ports operational\n", connStatus, it, strings.TrimPrefix(cvsVSLConnectOperStatus[it].String(), "CvsVSLConnectOperStatus"), operational, configured))
+		}
+
+		// VSL member ports
+		for _, it := range vslPorts {
+			portStatus := IcingaOK
+			if ifOperStatus[it] != IfOperStatusUp {
+				portStatus = IcingaWARN
+				problems = append(problems, fmt.Sprintf("VSL port %s is %s", ifName[it], strings.TrimPrefix(ifOperStatus[it].String(), "IfOperStatus")))
+			}
+			if portStatus > exitStatus {
+				exitStatus = portStatus
+			}
+			longOutput.WriteString(fmt.Sprintf("[%s]   %s: %s\n", portStatus, ifName[it], strings.TrimPrefix(ifOperStatus[it].String(), "IfOperStatus")))
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("Virtual switch domain %d: switch %d active, %d VSL ports up", domain, activeSwitch, len(vslPorts))
+		} else {
+			exitMsg = strings.Join(problems, "; ")
+		}
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String()})
+
+	},
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().IntVar(&expectedActive, "expect-active", 0, "Warn if this switch ID isn't the active chassis (default any)")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
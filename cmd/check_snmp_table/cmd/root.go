@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/common"
+	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/mib"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	g "github.com/gosnmp/gosnmp"
+)
+
+var verbosity int
+var conn g.GoSNMP
+var secparams g.UsmSecurityParameters
+var timeout int
+var seclevel SnmpV3MsgFlagsValue
+var authmode SnmpV3AuthProtocolValue
+var privmode SnmpV3PrivProtocolValue
+
+var definitionFile string
+
+// tableDefinition is the YAML file given with --definition
+type tableDefinition struct {
+	Name    string             `yaml:"name"`
+	Label   string             `yaml:"label"` // column used to name rows, default the index
+	Empty   string             `yaml:"empty"` // state when the table has no rows, default unknown
+	Columns []columnDefinition `yaml:"columns"`
+
+	emptyStatus IcingaStatusVal
+}
+
+type columnDefinition struct {
+	Name string `yaml:"name"`
+	OID  string `yaml:"oid"` // numeric, or a name known to the MIB registry eg. IF-MIB::ifOperStatus
+	// Join looks this column up using the value of another column as the index, instead of
+	// the row's index (eg. entPhysicalName by a column holding an entPhysicalIndex)
+	Join      string            `yaml:"join"`
+	States    map[string]string `yaml:"states"`     // value -> state
+	Default   string            `yaml:"default"`    // state for values not in states, default unknown
+	Warn      *float64          `yaml:"warn"`       // warn when value > this
+	Crit      *float64          `yaml:"crit"`       // critical when value > this
+	WarnBelow *float64          `yaml:"warn_below"` // warn when value < this
+	CritBelow *float64          `yaml:"crit_below"` // critical when value < this
+	Perfdata  bool              `yaml:"perfdata"`
+	UOM       string            `yaml:"uom"`
+
+	resolvedOID   string
+	states        map[string]IcingaStatusVal
+	defaultStatus IcingaStatusVal
+}
+
+var patternForNumericOID *regexp.Regexp = regexp.MustCompile(`^\.?\d+(\.\d+)*$`)
+var patternForPerfLabel *regexp.Regexp = regexp.MustCompile(`['=]`)
+
+var rootCmd = &cobra.Command{
+	Use:   "check_snmp_table",
+	Short: "Generic SNMP table check plugin",
+	Long: `Walks the columns of an SNMP table described in a YAML file, maps their values
+to states and thresholds, and reports each row.
+
+Example definition:
+
+  name: fans
+  label: descr
+  columns:
+    - name: descr
+      oid: 1.3.6.1.4.1.9.9.13.1.4.1.2
+    - name: state
+      oid: 1.3.6.1.4.1.9.9.13.1.4.1.3
+      states: {1: ok, 2: warn, 3: critical, 5: ok}
+      default: critical
+
+Rows come from the first column, every other column is matched to them by index
+(or by the value of the column named in 'join'). Columns can have 'states', numeric
+thresholds ('warn', 'crit', 'warn_below', 'crit_below') and 'perfdata: true' with an
+optional 'uom'. Thresholds follow the Icinga range semantics of the perfdata they
+produce: 'warn: 90' alerts above 90 (range ~:90) and 'warn_below: 10' below 10 (range
+10:). The row's state is the worst of its columns.
+
+Tables with composite indexes (eg. ifIndex.group) are supported.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		common.SetupLogging(verbosity)
+		slog.SetDefault(slog.With("target", conn.Target))
+		slog.Debug("Verbosity level set from cli argument", "verbosity", verbosity)
+
+		definition, err := loadDefinition(definitionFile)
+		if err != nil {
+			slog.Error("Unable to load table definition", "file", definitionFile, "error", err)
+			os.Exit(1)
+		}
+
+		conn.Version = g.Version3
+		conn.SecurityModel = g.UserSecurityModel
+		conn.MsgFlags = seclevel.Value
+		conn.Timeout = time.Duration(timeout) * time.Second
+		conn.MaxRepetitions = 10
+
+		secparams.AuthenticationProtocol = authmode.Value
+		secparams.PrivacyProtocol = privmode.Value
+		conn.SecurityParameters = secparams.Copy()
+
+		err = conn.Connect()
+		if err != nil {
+			slog.Error("Error occured when attempting to connect to device.", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Conn.Close()
+		slog.Debug("We have a connection to the device...")
+
+		// @Assumption: tables can have composite indexes, so the columns are walked into string
+		// keyed maps and joined on those (BulkWalkToMap only handles a single integer index)
+		columnValues := make(map[string]map[string]interface{})
+		for _, it := range definition.Columns {
+			result, err := common.BulkWalkToStringMap(&conn, it.resolvedOID)
+			if err != nil {
+				slog.Error("BulkWalk of device failed.", "oid", it.resolvedOID, "column", it.Name, "error", err)
+				os.Exit(1)
+			}
+			columnValues[it.Name] = result
+		}
+
+		var rows []string
+		for it_index := range columnValues[definition.Columns[0].Name] {
+			rows = append(rows, it_index)
+		}
+		sort.Slice(rows, func(i, j int) bool { return indexLess(rows[i], rows[j]) })
+
+		if len(rows) == 0 {
+			common.ExitPlugin(&IcingaStatus{Value: definition.emptyStatus, Message: fmt.Sprintf("No rows in %s", definition.Name)})
+		}
+
+		// Assume everything is ok, then check this assumption
+		var exitStatus IcingaStatusVal = IcingaOK
+		var problems []string
+		var longOutput strings.Builder
+		var perfData strings.Builder
+		numberOfRowsOk := 0
+
+		for _, row := range rows {
+			// resolve every column for this row first, so joins and the label can use them
+			values := make(map[string]interface{})
+			for _, it := range definition.Columns {
+				index := row
+				if it.Join != "" {
+					joined, ok := values[it.Join]
+					if !ok {
+						continue
+					}
+					index = fmt.Sprint(joined)
+				}
+				if v, ok := columnValues[it.Name][index]; ok {
+					values[it.Name] = v
+				}
+			}
+
+			label := row
+			if v, ok := values[definition.Label]; ok && definition.Label != "" && fmt.Sprint(v) != "" {
+				label = strings.TrimSpace(fmt.Sprint(v))
+			}
+
+			rowStatus := IcingaOK
+			var rowProblems []string
+			var columnStrings []string
+			for _, it := range definition.Columns {
+				v, ok := values[it.Name]
+				if !ok {
+					slog.Debug("Row has no value for column", "row", row, "column", it.Name)
+					continue
+				}
+				if it.Name == definition.Label && len(it.States) == 0 && it.Warn == nil && it.Crit == nil && it.WarnBelow == nil && it.CritBelow == nil {
+					continue
+				}
+
+				columnStatus := it.status(v)
+				if columnStatus > rowStatus {
+					rowStatus = columnStatus
+				}
+				if columnStatus != IcingaOK {
+					rowProblems = append(rowProblems, fmt.Sprintf("%s is %v", it.Name, v))
+				}
+				columnStrings = append(columnStrings, fmt.Sprintf("%s %v%s", it.Name, v, it.UOM))
+
+				if it.Perfdata {
+					if f, ok := toFloat(v); ok {
+						perfLabel := patternForPerfLabel.ReplaceAllString(label+"_"+it.Name, "_")
+						perfData.WriteString(fmt.Sprintf("'%s'=%g%s;%s;%s;; ", perfLabel, f, it.UOM, icingaRange(it.WarnBelow, it.Warn), icingaRange(it.CritBelow, it.Crit)))
+					}
+				}
+			}
+
+			if rowStatus == IcingaOK {
+				numberOfRowsOk += 1
+			} else {
+				problems = append(problems, fmt.Sprintf("%s: %s", label, strings.Join(rowProblems, ", ")))
+			}
+			if rowStatus > exitStatus {
+				exitStatus = rowStatus
+			}
+
+			longOutput.WriteString(fmt.Sprintf("[%s] %s: %s\n", rowStatus, label, strings.Join(columnStrings, ", ")))
+		}
+
+		var exitMsg string
+		if len(problems) == 0 {
+			exitMsg = fmt.Sprintf("All %d %s are ok", len(rows), definition.Name)
+		} else {
+			exitMsg = strings.Join(problems, "; ")
+		}
+		perfData.WriteString(fmt.Sprintf("'%s_ok'=%d;;;0;%d", patternForPerfLabel.ReplaceAllString(definition.Name, "_"), numberOfRowsOk, len(rows)))
+
+		common.ExitPlugin(&IcingaStatus{Value: exitStatus, Message: exitMsg, LongOutput: longOutput.String(), PerfData: perfData.String()})
+
+	},
+}
+
+// loadDefinition reads and validates the YAML table definition
+func loadDefinition(path string) (*tableDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition tableDefinition
+	if err := yaml.Unmarshal(data, &definition); err != nil {
+		return nil, err
+	}
+
+	if len(definition.Columns) == 0 {
+		return nil, fmt.Errorf("no columns defined")
+	}
+	if definition.Name == "" {
+		definition.Name = "rows"
+	}
+	definition.emptyStatus = IcingaUNKNOWN
+	if definition.Empty != "" {
+		if definition.emptyStatus, err = ParseIcingaStatus(definition.Empty); err != nil {
+			return nil, fmt.Errorf("empty: %w", err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for it_index := range definition.Columns {
+		it := &definition.Columns[it_index]
+		if it.Name == "" {
+			return nil, fmt.Errorf("column %d has no name", it_index+1)
+		}
+		if seen[it.Name] {
+			return nil, fmt.Errorf("column %s is defined more than once", it.Name)
+		}
+		if it.Join != "" && !seen[it.Join] {
+			return nil, fmt.Errorf("column %s joins on %s, which must be defined before it", it.Name, it.Join)
+		}
+		seen[it.Name] = true
+
+		if patternForNumericOID.MatchString(it.OID) {
+			it.resolvedOID = strings.TrimPrefix(it.OID, ".")
+		} else if it.resolvedOID, err = mib.OID(it.OID); err != nil {
+			return nil, fmt.Errorf("column %s: %w", it.Name, err)
+		}
+
+		it.defaultStatus = IcingaUNKNOWN
+		if it.Default != "" {
+			if it.defaultStatus, err = ParseIcingaStatus(it.Default); err != nil {
+				return nil, fmt.Errorf("column %s default: %w", it.Name, err)
+			}
+		}
+		it.states = make(map[string]IcingaStatusVal)
+		for value, state := range it.States {
+			if it.states[value], err = ParseIcingaStatus(state); err != nil {
+				return nil, fmt.Errorf("column %s state for %s: %w", it.Name, value, err)
+			}
+		}
+	}
+	if definition.Columns[0].Join != "" {
+		return nil, fmt.Errorf("the first column can't be joined, it decides the rows")
+	}
+	if definition.Label != "" && !seen[definition.Label] {
+		return nil, fmt.Errorf("label column %s isn't defined", definition.Label)
+	}
+
+	return &definition, nil
+}
+
+// status maps a value to a state with the column's states and thresholds, the worst wins
+func (c *columnDefinition) status(value interface{}) IcingaStatusVal {
+	status := IcingaOK
+	if len(c.States) > 0 {
+		var ok bool
+		if status, ok = c.states[fmt.Sprint(value)]; !ok {
+			status = c.defaultStatus
+		}
+	}
+
+	f, isNumber := toFloat(value)
+	if !isNumber {
+		return status
+	}
+	switch {
+	case (c.Crit != nil && f > *c.Crit) || (c.CritBelow != nil && f < *c.CritBelow):
+		status = max(status, IcingaCRITICAL)
+	case (c.Warn != nil && f > *c.Warn) || (c.WarnBelow != nil && f < *c.WarnBelow):
+		status = max(status, IcingaWARN)
+	}
+	return status
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// icingaRange builds the warn or crit part of perfdata from the thresholds, eg. "10:90"
+func icingaRange(below *float64, above *float64) string {
+	switch {
+	case below != nil && above != nil:
+		return fmt.Sprintf("%g:%g", *below, *above)
+	case below != nil:
+		return fmt.Sprintf("%g:", *below)
+	case above != nil:
+		return fmt.Sprintf("~:%g", *above)
+	default:
+		return ""
+	}
+}
+
+// indexLess sorts indexes like "1.10" after "1.9"
+func indexLess(a string, b string) bool {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr != nil || bErr != nil {
+			if as[i] != bs[i] {
+				return as[i] < bs[i]
+			}
+			continue
+		}
+		if an != bn {
+			return an < bn
+		}
+	}
+	return len(as) < len(bs)
+}
+
+func init() {
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Increase verbosity (-v, -vv, -vvv)")
+
+	// connection flags
+	rootCmd.PersistentFlags().StringVarP(&conn.Target, "host", "H", "", "Hostname or IP address to run the check against (required)")
+	rootCmd.MarkPersistentFlagRequired("host")
+	rootCmd.PersistentFlags().Uint16VarP(&conn.Port, "port", "p", 161, "Port remote device SNMP agent is listening on")
+	rootCmd.PersistentFlags().IntVarP(&timeout, "timeout", "t", 10, "Seconds to wait before timing out")
+
+	// snmpv3 flags
+	rootCmd.PersistentFlags().StringVarP(&secparams.UserName, "user", "u", "", "SNMPv3 user name (required)")
+	rootCmd.MarkPersistentFlagRequired("user")
+	rootCmd.PersistentFlags().VarP(&seclevel, "seclevel", "l", "SNMPv3 Security Level")
+	rootCmd.PersistentFlags().StringVarP(&secparams.AuthenticationPassphrase, "authkey", "A", "", "SNMPv3 auth key (required)")
+	rootCmd.MarkPersistentFlagRequired("authkey")
+	rootCmd.PersistentFlags().StringVarP(&secparams.PrivacyPassphrase, "privkey", "X", "", "SNMPv3 priv key (required)")
+	rootCmd.MarkPersistentFlagRequired("privkey")
+	rootCmd.PersistentFlags().VarP(&authmode, "authmode", "a", "SNMPv3 Auth Mode")
+	rootCmd.PersistentFlags().VarP(&privmode, "privmode", "x", "SNMPv3 Privacy Mode")
+
+	// check specific flags
+	rootCmd.PersistentFlags().StringVarP(&definitionFile, "definition", "d", "", "YAML file describing the table to check (required)")
+	rootCmd.MarkPersistentFlagRequired("definition")
+}
+
+func Execute() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
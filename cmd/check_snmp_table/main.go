@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/jamiereid/go-icingaplugins/cmd/check_snmp_table/cmd"
+)
+
+func main() {
+	cmd.Execute()
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -11,11 +12,27 @@ import (
 	g "github.com/gosnmp/gosnmp"
 
 	. "github.com/jamiereid/go-icingaplugins/internal/pkg/common/types"
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/mib"
 )
 
 const LevelTrace = slog.Level(-6)
 
+// resolveOID turns a symbolic OID (eg. "CISCO-STACKWISE-MIB::cswSwitchState") into a numeric
+// one, so the checks can use names without the MIBs being parsed until they're first needed.
+// Numeric OIDs are returned unchanged.
+func resolveOID(oid string) (string, error) {
+	if !strings.Contains(oid, "::") {
+		return oid, nil
+	}
+	return mib.OID(oid)
+}
+
 func SnmpGet(conn *g.GoSNMP, oid string) (any, error) {
+	oid, err := resolveOID(oid)
+	if err != nil {
+		return nil, err
+	}
+
 	pdu, err := conn.Get([]string{oid})
 	if err != nil {
 		return nil, fmt.Errorf("Error when attempting to get %s: %w", oid, err)
@@ -24,6 +41,7 @@ func SnmpGet(conn *g.GoSNMP, oid string) (any, error) {
 	if pdu.Error != g.NoError {
 		return nil, fmt.Errorf("SNMP Error: %v", pdu.Error.String())
 	}
+	tracePDU(pdu.Variables[0])
 
 	if pdu.Variables[0].Type == g.NoSuchInstance {
 		return nil, fmt.Errorf("SNMP Response: No Such Instance")
@@ -42,6 +60,8 @@ func SnmpGet(conn *g.GoSNMP, oid string) (any, error) {
 		return uint32(pdu.Variables[0].Value.(uint)), nil
 	case g.Counter64:
 		return pdu.Variables[0].Value.(uint64), nil
+	case g.IPAddress, g.ObjectIdentifier:
+		return pdu.Variables[0].Value.(string), nil
 	default:
 		return nil, fmt.Errorf("unsupported SNMP type: %v", pdu.Variables[0].Type)
 	}
@@ -50,12 +70,18 @@ func SnmpGet(conn *g.GoSNMP, oid string) (any, error) {
 func BulkWalkToStringMap(conn *g.GoSNMP, oid string) (map[string]interface{}, error) {
 	var returnMap = make(map[string]interface{})
 
+	oid, err := resolveOID(oid)
+	if err != nil {
+		return nil, err
+	}
+
 	// @TODO: Check for start and end in "." - only concat if we need to
 	prefixBytes := []byte("." + oid + ".")
-	err := conn.BulkWalk(oid, func(pdu g.SnmpPDU) error {
+	err = conn.BulkWalk(oid, func(pdu g.SnmpPDU) error {
 		if pdu.Value == nil {
 			return fmt.Errorf("recieved a PDU with a nil value")
 		}
+		tracePDU(pdu)
 
 		nameBytes := []byte(pdu.Name)
 		if len(nameBytes) <= len(prefixBytes) || string(nameBytes[:len(prefixBytes)]) != string(prefixBytes) {
@@ -74,6 +100,8 @@ func BulkWalkToStringMap(conn *g.GoSNMP, oid string) (map[string]interface{}, er
 			returnMap[key] = uint32(pdu.Value.(uint))
 		case g.Counter64:
 			returnMap[key] = pdu.Value.(uint64)
+		case g.IPAddress, g.ObjectIdentifier:
+			returnMap[key] = pdu.Value.(string)
 		default:
 			return fmt.Errorf("unsupported SNMP type: %v", pdu.Type)
 		}
@@ -92,12 +120,18 @@ func BulkWalkToStringMap(conn *g.GoSNMP, oid string) (map[string]interface{}, er
 func BulkWalkToMap(conn *g.GoSNMP, oid string) (map[int]interface{}, error) {
 	var returnMap = make(map[int]interface{})
 
+	oid, err := resolveOID(oid)
+	if err != nil {
+		return nil, err
+	}
+
 	// @TODO: Check for start and end in "." - only concat if we need to
 	prefixBytes := []byte("." + oid + ".")
-	err := conn.BulkWalk(oid, func(pdu g.SnmpPDU) error {
+	err = conn.BulkWalk(oid, func(pdu g.SnmpPDU) error {
 		if pdu.Value == nil {
 			return fmt.Errorf("recieved a PDU with a nil value")
 		}
+		tracePDU(pdu)
 
 		nameBytes := []byte(pdu.Name)
 		if len(nameBytes) <= len(prefixBytes) || string(nameBytes[:len(prefixBytes)]) != string(prefixBytes) {
@@ -119,6 +153,8 @@ func BulkWalkToMap(conn *g.GoSNMP, oid string) (map[int]interface{}, error) {
 			returnMap[key] = uint32(pdu.Value.(uint))
 		case g.Counter64:
 			returnMap[key] = pdu.Value.(uint64)
+		case g.IPAddress, g.ObjectIdentifier:
+			returnMap[key] = pdu.Value.(string)
 		default:
 			return fmt.Errorf("unsupported SNMP type: %v", pdu.Type)
 		}
@@ -146,6 +182,13 @@ func ExitPlugin(status *IcingaStatus) {
 		exitMsg.WriteString(status.PerfData)
 	}
 
+	// long output goes after the first line so that icinga keeps the perfdata
+	// attached to the summary
+	if status.LongOutput != "" {
+		exitMsg.WriteString("\n")
+		exitMsg.WriteString(strings.TrimSuffix(status.LongOutput, "\n"))
+	}
+
 	fmt.Fprintln(os.Stdout, exitMsg.String())
 	os.Exit(int(status.Value))
 }
@@ -297,6 +340,44 @@ func GetDeviceModel(params *g.GoSNMP) (*CiscoModelFamily, string, error) {
 	return &returnValue, deviceModelAsString, nil
 }
 
+// Like GetDeviceModel, but identifies the device by sysObjectID rather than the entity table.
+func GetDeviceModelFromSysObjectID(params *g.GoSNMP) (*CiscoModelFamily, string, error) {
+
+	err := params.Connect()
+	if err != nil {
+		return nil, "", fmt.Errorf("Error when connecting: %w", err)
+	}
+	defer params.Conn.Close()
+
+	raw, err := SnmpGet(params, "1.3.6.1.2.1.1.2.0") // sysObjectID
+	if err != nil {
+		return nil, "", fmt.Errorf("Error when attempting to get sysObjectID: %w", err)
+	}
+	sysObjectID, ok := raw.(string)
+	if !ok {
+		return nil, "", fmt.Errorf("sysObjectID is not an OID: %v", raw)
+	}
+
+	deviceModelAsString := CiscoModelFromSysObjectID(sysObjectID)
+	if deviceModelAsString == "" {
+		// ASAs we don't have a sysObjectID for (new models, Firepower running ASA) still say
+		// what they are in sysDescr, eg. "Cisco Adaptive Security Appliance Version 9.16(4)"
+		raw, err := SnmpGet(params, "1.3.6.1.2.1.1.1.0") // sysDescr
+		if err != nil {
+			return nil, "", fmt.Errorf("Error when attempting to get sysDescr: %w", err)
+		}
+		if sysDescr, ok := raw.(string); ok && strings.Contains(sysDescr, "Adaptive Security Appliance") {
+			slog.Debug("sysObjectID is not a known ASA, but sysDescr says it is one", "sysObjectID", sysObjectID)
+			deviceModelAsString = "ASA"
+		} else {
+			deviceModelAsString = sysObjectID
+		}
+	}
+
+	returnValue := NewCiscoModelFamily(deviceModelAsString)
+	return &returnValue, deviceModelAsString, nil
+}
+
 // Get indexes of IanaPhysicalClassChassis
 func GetStackMembers(params *g.GoSNMP) ([]int, error) {
 
@@ -335,14 +416,107 @@ func GetStackMembers(params *g.GoSNMP) ([]int, error) {
 	return returnSlice, nil
 }
 
+// Get ifName for every interface, keyed by ifIndex. The connection must already be open.
+func GetIfNames(conn *g.GoSNMP) (map[int]string, error) {
+	return bulkWalkToStringValues(conn, "1.3.6.1.2.1.31.1.1.1.1") // ifName
+}
+
+// Get ifAlias (the interface description) for every interface, keyed by ifIndex. The connection
+// must already be open.
+func GetIfAliases(conn *g.GoSNMP) (map[int]string, error) {
+	return bulkWalkToStringValues(conn, "1.3.6.1.2.1.31.1.1.1.18") // ifAlias
+}
+
+// Get the entPhysicalTable (class, name, model, serial and revisions), keyed by entPhysicalIndex. The
+// connection must already be open.
+func GetEntPhysicalEntries(conn *g.GoSNMP) (map[int]EntPhysicalEntry, error) {
+	result, err := BulkWalkToMap(conn, "1.3.6.1.2.1.47.1.1.1.1.5") // entPhysicalClass
+	if err != nil {
+		return nil, fmt.Errorf("BulkWalk of device (%v) failed: %w", conn.Target, err)
+	}
+
+	returnMap := make(map[int]EntPhysicalEntry)
+	for it_index, it := range result {
+		v, ok := it.(int)
+		if !ok {
+			slog.Warn("Unable to convert value to int", "oid", "1.3.6.1.2.1.47.1.1.1.1.5", "key", it_index, "raw_value", it)
+			continue
+		}
+		returnMap[it_index] = EntPhysicalEntry{Index: it_index, Class: SnmpIanaPhysicalClass(v)}
+	}
+
+	columns := []struct {
+		oid   string
+		field func(*EntPhysicalEntry) *string
+	}{
+		{"1.3.6.1.2.1.47.1.1.1.1.2", func(e *EntPhysicalEntry) *string { return &e.Descr }},        // entPhysicalDescr
+		{"1.3.6.1.2.1.47.1.1.1.1.7", func(e *EntPhysicalEntry) *string { return &e.Name }},         // entPhysicalName
+		{"1.3.6.1.2.1.47.1.1.1.1.8", func(e *EntPhysicalEntry) *string { return &e.HardwareRev }},  // entPhysicalHardwareRev
+		{"1.3.6.1.2.1.47.1.1.1.1.9", func(e *EntPhysicalEntry) *string { return &e.FirmwareRev }},  // entPhysicalFirmwareRev
+		{"1.3.6.1.2.1.47.1.1.1.1.10", func(e *EntPhysicalEntry) *string { return &e.SoftwareRev }}, // entPhysicalSoftwareRev
+		{"1.3.6.1.2.1.47.1.1.1.1.11", func(e *EntPhysicalEntry) *string { return &e.SerialNum }},   // entPhysicalSerialNum
+		{"1.3.6.1.2.1.47.1.1.1.1.13", func(e *EntPhysicalEntry) *string { return &e.ModelName }},   // entPhysicalModelName
+	}
+	for _, column := range columns {
+		values, err := bulkWalkToStringValues(conn, column.oid)
+		if err != nil {
+			return nil, err
+		}
+		for it_index, it := range values {
+			entry, ok := returnMap[it_index]
+			if !ok {
+				continue
+			}
+			*column.field(&entry) = strings.TrimSpace(it)
+			returnMap[it_index] = entry
+		}
+	}
+
+	return returnMap, nil
+}
+
+func bulkWalkToStringValues(conn *g.GoSNMP, oid string) (map[int]string, error) {
+	result, err := BulkWalkToMap(conn, oid)
+	if err != nil {
+		return nil, fmt.Errorf("BulkWalk of device (%v) failed: %w", conn.Target, err)
+	}
+
+	returnMap := make(map[int]string)
+	for it_index, it := range result {
+		v, ok := it.(string)
+		if !ok {
+			slog.Warn("Unable to convert value to string", "oid", oid, "key", it_index, "raw_value", it)
+			continue
+		}
+		returnMap[it_index] = v
+	}
+
+	return returnMap, nil
+}
+
 func DebugPrint(pdu g.SnmpPDU) {
 	switch pdu.Type {
 	case g.OctetString:
 		b := pdu.Value.([]byte)
-		fmt.Printf("STRING: %s\n", string(b))
+		fmt.Printf("%s = STRING: %s\n", mib.Name(pdu.Name), string(b))
 	default:
-		fmt.Printf("TYPE %v: %d\n", g.Asn1BER(pdu.Type), g.ToBigInt(pdu.Value))
+		fmt.Printf("%s = TYPE %v: %d\n", mib.Name(pdu.Name), g.Asn1BER(pdu.Type), g.ToBigInt(pdu.Value))
+	}
+}
+
+// tracePDU logs a PDU received from the device with its OID translated to a name, at trace
+// level (-vvvv) so it costs nothing otherwise
+func tracePDU(pdu g.SnmpPDU) {
+	ctx := context.Background()
+	if !slog.Default().Enabled(ctx, LevelTrace) {
+		return
+	}
+
+	value := pdu.Value
+	if b, ok := value.([]byte); ok {
+		value = string(b)
 	}
+	slog.Log(ctx, LevelTrace, "Received PDU", "oid", mib.Name(pdu.Name), "type", pdu.Type, "value", value)
 }
 
 // SetupLogging configures the slog logger based on the verbosity level
@@ -1,5 +1,7 @@
 package types
 
+import "strings"
+
 type CiscoModelFamily uint8
 
 const (
@@ -19,6 +21,7 @@ const (
 	CiscoModelFamily3560
 	CiscoModelFamily2960X
 	CiscoModelFamily2960
+	CiscoModelFamilyASA
 )
 
 func NewCiscoModelFamily(input string) CiscoModelFamily {
@@ -53,6 +56,8 @@ func NewCiscoModelFamily(input string) CiscoModelFamily {
 		return CiscoModelFamily2960X
 	case "2960", "C2960", "2960S", "C2960S":
 		return CiscoModelFamily2960
+	case "ASA", "ASA5505", "ASA5510", "ASA5520", "ASA5540", "ASA5550", "ASA5580", "ASA5585", "ASA5512", "ASA5515", "ASA5525", "ASA5545", "ASA5555", "ASA5506", "ASA5508", "ASA5516", "ASAv":
+		return CiscoModelFamilyASA
 	default:
 		return CiscoModelFamilyUnknown
 	}
@@ -90,7 +95,56 @@ func (c CiscoModelFamily) String() string {
 		return "Cisco 2960X Family"
 	case CiscoModelFamily2960:
 		return "Cisco 2960 Family"
+	case CiscoModelFamilyASA:
+		return "Cisco ASA Family"
 	default:
 		return "Model family not recognized"
 	}
 }
+
+// Firewalls don't reliably populate entPhysicalModelName (multi-context ASAs
+// hide the chassis entirely), so they're identified by sysObjectID instead.
+// These are from CISCO-PRODUCTS-MIB, the multi-context ("sc") variants map to
+// the same model. Anything not listed here (eg. Firepower running ASA, which
+// has a sysObjectID per chassis) is found by sysDescr instead, see
+// GetDeviceModelFromSysObjectID.
+// @Assumption: the 5506-X/5508-X/5516-X and ASAv values haven't been checked
+// against a device yet
+var ciscoProductsToModel = map[string]string{
+	"1.3.6.1.4.1.9.1.669":  "ASA5510",
+	"1.3.6.1.4.1.9.1.670":  "ASA5520",
+	"1.3.6.1.4.1.9.1.671":  "ASA5520", // ciscoASA5520sc
+	"1.3.6.1.4.1.9.1.672":  "ASA5540",
+	"1.3.6.1.4.1.9.1.673":  "ASA5540", // ciscoASA5540sc
+	"1.3.6.1.4.1.9.1.745":  "ASA5505",
+	"1.3.6.1.4.1.9.1.753":  "ASA5550",
+	"1.3.6.1.4.1.9.1.773":  "ASA5510", // ciscoASA5510sc
+	"1.3.6.1.4.1.9.1.914":  "ASA5580",
+	"1.3.6.1.4.1.9.1.1165": "ASA5585", // ciscoASA5585Ssp10
+	"1.3.6.1.4.1.9.1.1166": "ASA5585", // ciscoASA5585Ssp20
+	"1.3.6.1.4.1.9.1.1167": "ASA5585", // ciscoASA5585Ssp40
+	"1.3.6.1.4.1.9.1.1168": "ASA5585", // ciscoASA5585Ssp60
+	"1.3.6.1.4.1.9.1.1169": "ASA5585", // ciscoASA5585Ssp10sc
+	"1.3.6.1.4.1.9.1.1170": "ASA5585", // ciscoASA5585Ssp20sc
+	"1.3.6.1.4.1.9.1.1171": "ASA5585", // ciscoASA5585Ssp40sc
+	"1.3.6.1.4.1.9.1.1172": "ASA5585", // ciscoASA5585Ssp60sc
+	"1.3.6.1.4.1.9.1.1407": "ASA5512",
+	"1.3.6.1.4.1.9.1.1408": "ASA5525",
+	"1.3.6.1.4.1.9.1.1409": "ASA5545",
+	"1.3.6.1.4.1.9.1.1410": "ASA5555",
+	"1.3.6.1.4.1.9.1.1411": "ASA5512", // ciscoASA5512sc
+	"1.3.6.1.4.1.9.1.1412": "ASA5525", // ciscoASA5525sc
+	"1.3.6.1.4.1.9.1.1413": "ASA5545", // ciscoASA5545sc
+	"1.3.6.1.4.1.9.1.1414": "ASA5555", // ciscoASA5555sc
+	"1.3.6.1.4.1.9.1.1421": "ASA5515",
+	"1.3.6.1.4.1.9.1.1422": "ASA5515", // ciscoASA5515sc
+	"1.3.6.1.4.1.9.1.1902": "ASAv",
+	"1.3.6.1.4.1.9.1.2114": "ASA5506",
+	"1.3.6.1.4.1.9.1.2117": "ASA5508",
+	"1.3.6.1.4.1.9.1.2118": "ASA5516",
+}
+
+// Returns the model (eg. "ASA5525") for a sysObjectID, or "" if it isn't one we know about.
+func CiscoModelFromSysObjectID(oid string) string {
+	return ciscoProductsToModel[strings.TrimPrefix(oid, ".")]
+}
@@ -0,0 +1,14 @@
+package types
+
+// A row of the ENTITY-MIB entPhysicalTable, as returned by common.GetEntPhysicalEntries
+type EntPhysicalEntry struct {
+	Index       int
+	Class       SnmpIanaPhysicalClass
+	Descr       string
+	Name        string
+	HardwareRev string
+	FirmwareRev string
+	SoftwareRev string
+	SerialNum   string
+	ModelName   string
+}
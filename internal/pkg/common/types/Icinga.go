@@ -1,6 +1,10 @@
 package types
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 type IcingaStatusVal uint8
 
@@ -26,8 +30,26 @@ func (c IcingaStatusVal) String() string {
 	}
 }
 
+var validValuesIcingaStatus = map[string]IcingaStatusVal{
+	"ok":       IcingaOK,
+	"warn":     IcingaWARN,
+	"warning":  IcingaWARN,
+	"crit":     IcingaCRITICAL,
+	"critical": IcingaCRITICAL,
+	"unknown":  IcingaUNKNOWN,
+}
+
+// ParseIcingaStatus parses a state as written by users in flags and config files, eg. "warn"
+func ParseIcingaStatus(value string) (IcingaStatusVal, error) {
+	if status, ok := validValuesIcingaStatus[strings.ToLower(value)]; ok {
+		return status, nil
+	}
+	return IcingaUNKNOWN, errors.New("invalid state " + value + ", valid options are: ok, warn, critical, unknown")
+}
+
 type IcingaStatus struct {
-	Message  string
-	PerfData string
-	Value    IcingaStatusVal
+	Message    string
+	LongOutput string
+	PerfData   string
+	Value      IcingaStatusVal
 }
@@ -0,0 +1,21 @@
+package types
+
+import "fmt"
+
+type SnmpBgpPeerAdminStatus uint8
+
+const (
+	BgpPeerAdminStatusStop SnmpBgpPeerAdminStatus = iota + 1
+	BgpPeerAdminStatusStart
+)
+
+func (c SnmpBgpPeerAdminStatus) String() string {
+	switch c {
+	case BgpPeerAdminStatusStop:
+		return "BgpPeerAdminStatusStop"
+	case BgpPeerAdminStatusStart:
+		return "BgpPeerAdminStatusStart"
+	default:
+		return fmt.Sprintf("UnknownBgpPeerAdminStatus(%d)", c)
+	}
+}
@@ -0,0 +1,33 @@
+package types
+
+import "fmt"
+
+type SnmpBgpPeerState uint8
+
+const (
+	BgpPeerStateIdle SnmpBgpPeerState = iota + 1
+	BgpPeerStateConnect
+	BgpPeerStateActive
+	BgpPeerStateOpenSent
+	BgpPeerStateOpenConfirm
+	BgpPeerStateEstablished
+)
+
+func (c SnmpBgpPeerState) String() string {
+	switch c {
+	case BgpPeerStateIdle:
+		return "BgpPeerStateIdle"
+	case BgpPeerStateConnect:
+		return "BgpPeerStateConnect"
+	case BgpPeerStateActive:
+		return "BgpPeerStateActive"
+	case BgpPeerStateOpenSent:
+		return "BgpPeerStateOpenSent"
+	case BgpPeerStateOpenConfirm:
+		return "BgpPeerStateOpenConfirm"
+	case BgpPeerStateEstablished:
+		return "BgpPeerStateEstablished"
+	default:
+		return fmt.Sprintf("UnknownBgpPeerState(%d)", c)
+	}
+}
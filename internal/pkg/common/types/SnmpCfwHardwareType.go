@@ -0,0 +1,39 @@
+package types
+
+import "fmt"
+
+type SnmpCfwHardwareType uint8
+
+const (
+	CfwHardwareTypeMemory SnmpCfwHardwareType = iota + 1
+	CfwHardwareTypeDisk
+	CfwHardwareTypePower
+	CfwHardwareTypeNetInterface
+	CfwHardwareTypeCpu
+	CfwHardwareTypePrimaryUnit
+	CfwHardwareTypeSecondaryUnit
+	CfwHardwareTypeOther
+)
+
+func (c SnmpCfwHardwareType) String() string {
+	switch c {
+	case CfwHardwareTypeMemory:
+		return "CfwHardwareTypeMemory"
+	case CfwHardwareTypeDisk:
+		return "CfwHardwareTypeDisk"
+	case CfwHardwareTypePower:
+		return "CfwHardwareTypePower"
+	case CfwHardwareTypeNetInterface:
+		return "CfwHardwareTypeNetInterface"
+	case CfwHardwareTypeCpu:
+		return "CfwHardwareTypeCpu"
+	case CfwHardwareTypePrimaryUnit:
+		return "CfwHardwareTypePrimaryUnit"
+	case CfwHardwareTypeSecondaryUnit:
+		return "CfwHardwareTypeSecondaryUnit"
+	case CfwHardwareTypeOther:
+		return "CfwHardwareTypeOther"
+	default:
+		return fmt.Sprintf("UnknownCfwHardwareType(%d)", c)
+	}
+}
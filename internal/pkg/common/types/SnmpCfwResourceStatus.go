@@ -0,0 +1,45 @@
+package types
+
+import "fmt"
+
+type SnmpCfwResourceStatus uint8
+
+const (
+	CfwResourceStatusOther SnmpCfwResourceStatus = iota + 1
+	CfwResourceStatusUp
+	CfwResourceStatusDown
+	CfwResourceStatusError
+	CfwResourceStatusOverTemp
+	CfwResourceStatusBusy
+	CfwResourceStatusNoMedia
+	CfwResourceStatusBackup
+	CfwResourceStatusActive
+	CfwResourceStatusStandby
+)
+
+func (c SnmpCfwResourceStatus) String() string {
+	switch c {
+	case CfwResourceStatusOther:
+		return "CfwResourceStatusOther"
+	case CfwResourceStatusUp:
+		return "CfwResourceStatusUp"
+	case CfwResourceStatusDown:
+		return "CfwResourceStatusDown"
+	case CfwResourceStatusError:
+		return "CfwResourceStatusError"
+	case CfwResourceStatusOverTemp:
+		return "CfwResourceStatusOverTemp"
+	case CfwResourceStatusBusy:
+		return "CfwResourceStatusBusy"
+	case CfwResourceStatusNoMedia:
+		return "CfwResourceStatusNoMedia"
+	case CfwResourceStatusBackup:
+		return "CfwResourceStatusBackup"
+	case CfwResourceStatusActive:
+		return "CfwResourceStatusActive"
+	case CfwResourceStatusStandby:
+		return "CfwResourceStatusStandby"
+	default:
+		return fmt.Sprintf("UnknownCfwResourceStatus(%d)", c)
+	}
+}
@@ -0,0 +1,24 @@
+package types
+
+import "fmt"
+
+type SnmpCvsChassisRole uint8
+
+const (
+	CvsChassisRoleStandalone SnmpCvsChassisRole = iota + 1
+	CvsChassisRoleActive
+	CvsChassisRoleStandby
+)
+
+func (c SnmpCvsChassisRole) String() string {
+	switch c {
+	case CvsChassisRoleStandalone:
+		return "CvsChassisRoleStandalone"
+	case CvsChassisRoleActive:
+		return "CvsChassisRoleActive"
+	case CvsChassisRoleStandby:
+		return "CvsChassisRoleStandby"
+	default:
+		return fmt.Sprintf("UnknownCvsChassisRole(%d)", c)
+	}
+}
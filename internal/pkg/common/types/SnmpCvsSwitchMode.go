@@ -0,0 +1,21 @@
+package types
+
+import "fmt"
+
+type SnmpCvsSwitchMode uint8
+
+const (
+	CvsSwitchModeStandalone SnmpCvsSwitchMode = iota + 1
+	CvsSwitchModeMultiNode
+)
+
+func (c SnmpCvsSwitchMode) String() string {
+	switch c {
+	case CvsSwitchModeStandalone:
+		return "CvsSwitchModeStandalone"
+	case CvsSwitchModeMultiNode:
+		return "CvsSwitchModeMultiNode"
+	default:
+		return fmt.Sprintf("UnknownCvsSwitchMode(%d)", c)
+	}
+}
@@ -0,0 +1,21 @@
+package types
+
+import "fmt"
+
+type SnmpCvsVSLConnectOperStatus uint8
+
+const (
+	CvsVSLConnectOperStatusUp SnmpCvsVSLConnectOperStatus = iota + 1
+	CvsVSLConnectOperStatusDown
+)
+
+func (c SnmpCvsVSLConnectOperStatus) String() string {
+	switch c {
+	case CvsVSLConnectOperStatusUp:
+		return "CvsVSLConnectOperStatusUp"
+	case CvsVSLConnectOperStatusDown:
+		return "CvsVSLConnectOperStatusDown"
+	default:
+		return fmt.Sprintf("UnknownCvsVSLConnectOperStatus(%d)", c)
+	}
+}
@@ -0,0 +1,66 @@
+package types
+
+import "fmt"
+
+type SnmpEntSensorDataScale uint8
+
+const (
+	EntSensorDataScaleYocto SnmpEntSensorDataScale = iota + 1
+	EntSensorDataScaleZepto
+	EntSensorDataScaleAtto
+	EntSensorDataScaleFemto
+	EntSensorDataScalePico
+	EntSensorDataScaleNano
+	EntSensorDataScaleMicro
+	EntSensorDataScaleMilli
+	EntSensorDataScaleUnits
+	EntSensorDataScaleKilo
+	EntSensorDataScaleMega
+	EntSensorDataScaleGiga
+	EntSensorDataScaleTera
+	EntSensorDataScaleExa
+	EntSensorDataScalePeta
+	EntSensorDataScaleZetta
+	EntSensorDataScaleYotta
+)
+
+func (c SnmpEntSensorDataScale) String() string {
+	switch c {
+	case EntSensorDataScaleYocto:
+		return "EntSensorDataScaleYocto"
+	case EntSensorDataScaleZepto:
+		return "EntSensorDataScaleZepto"
+	case EntSensorDataScaleAtto:
+		return "EntSensorDataScaleAtto"
+	case EntSensorDataScaleFemto:
+		return "EntSensorDataScaleFemto"
+	case EntSensorDataScalePico:
+		return "EntSensorDataScalePico"
+	case EntSensorDataScaleNano:
+		return "EntSensorDataScaleNano"
+	case EntSensorDataScaleMicro:
+		return "EntSensorDataScaleMicro"
+	case EntSensorDataScaleMilli:
+		return "EntSensorDataScaleMilli"
+	case EntSensorDataScaleUnits:
+		return "EntSensorDataScaleUnits"
+	case EntSensorDataScaleKilo:
+		return "EntSensorDataScaleKilo"
+	case EntSensorDataScaleMega:
+		return "EntSensorDataScaleMega"
+	case EntSensorDataScaleGiga:
+		return "EntSensorDataScaleGiga"
+	case EntSensorDataScaleTera:
+		return "EntSensorDataScaleTera"
+	case EntSensorDataScaleExa:
+		return "EntSensorDataScaleExa"
+	case EntSensorDataScalePeta:
+		return "EntSensorDataScalePeta"
+	case EntSensorDataScaleZetta:
+		return "EntSensorDataScaleZetta"
+	case EntSensorDataScaleYotta:
+		return "EntSensorDataScaleYotta"
+	default:
+		return fmt.Sprintf("UnknownEntSensorDataScale(%d)", c)
+	}
+}
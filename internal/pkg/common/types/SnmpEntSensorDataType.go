@@ -0,0 +1,60 @@
+package types
+
+import "fmt"
+
+type SnmpEntSensorDataType uint8
+
+const (
+	EntSensorDataTypeOther SnmpEntSensorDataType = iota + 1
+	EntSensorDataTypeUnknown
+	EntSensorDataTypeVoltsAC
+	EntSensorDataTypeVoltsDC
+	EntSensorDataTypeAmperes
+	EntSensorDataTypeWatts
+	EntSensorDataTypeHertz
+	EntSensorDataTypeCelsius
+	EntSensorDataTypePercentRH
+	EntSensorDataTypeRpm
+	EntSensorDataTypeCmm
+	EntSensorDataTypeTruthValue
+	EntSensorDataTypeSpecialEnum
+	EntSensorDataTypeDBm
+	EntSensorDataTypeDB
+)
+
+func (c SnmpEntSensorDataType) String() string {
+	switch c {
+	case EntSensorDataTypeOther:
+		return "EntSensorDataTypeOther"
+	case EntSensorDataTypeUnknown:
+		return "EntSensorDataTypeUnknown"
+	case EntSensorDataTypeVoltsAC:
+		return "EntSensorDataTypeVoltsAC"
+	case EntSensorDataTypeVoltsDC:
+		return "EntSensorDataTypeVoltsDC"
+	case EntSensorDataTypeAmperes:
+		return "EntSensorDataTypeAmperes"
+	case EntSensorDataTypeWatts:
+		return "EntSensorDataTypeWatts"
+	case EntSensorDataTypeHertz:
+		return "EntSensorDataTypeHertz"
+	case EntSensorDataTypeCelsius:
+		return "EntSensorDataTypeCelsius"
+	case EntSensorDataTypePercentRH:
+		return "EntSensorDataTypePercentRH"
+	case EntSensorDataTypeRpm:
+		return "EntSensorDataTypeRpm"
+	case EntSensorDataTypeCmm:
+		return "EntSensorDataTypeCmm"
+	case EntSensorDataTypeTruthValue:
+		return "EntSensorDataTypeTruthValue"
+	case EntSensorDataTypeSpecialEnum:
+		return "EntSensorDataTypeSpecialEnum"
+	case EntSensorDataTypeDBm:
+		return "EntSensorDataTypeDBm"
+	case EntSensorDataTypeDB:
+		return "EntSensorDataTypeDB"
+	default:
+		return fmt.Sprintf("UnknownEntSensorDataType(%d)", c)
+	}
+}
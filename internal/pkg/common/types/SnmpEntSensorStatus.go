@@ -0,0 +1,24 @@
+package types
+
+import "fmt"
+
+type SnmpEntSensorStatus uint8
+
+const (
+	EntSensorStatusOk SnmpEntSensorStatus = iota + 1
+	EntSensorStatusUnavailable
+	EntSensorStatusNonOperational
+)
+
+func (c SnmpEntSensorStatus) String() string {
+	switch c {
+	case EntSensorStatusOk:
+		return "EntSensorStatusOk"
+	case EntSensorStatusUnavailable:
+		return "EntSensorStatusUnavailable"
+	case EntSensorStatusNonOperational:
+		return "EntSensorStatusNonOperational"
+	default:
+		return fmt.Sprintf("UnknownEntSensorStatus(%d)", c)
+	}
+}
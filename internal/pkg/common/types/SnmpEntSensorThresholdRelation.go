@@ -0,0 +1,33 @@
+package types
+
+import "fmt"
+
+type SnmpEntSensorThresholdRelation uint8
+
+const (
+	EntSensorThresholdRelationLessThan SnmpEntSensorThresholdRelation = iota + 1
+	EntSensorThresholdRelationLessOrEqual
+	EntSensorThresholdRelationGreaterThan
+	EntSensorThresholdRelationGreaterOrEqual
+	EntSensorThresholdRelationEqualTo
+	EntSensorThresholdRelationNotEqualTo
+)
+
+func (c SnmpEntSensorThresholdRelation) String() string {
+	switch c {
+	case EntSensorThresholdRelationLessThan:
+		return "EntSensorThresholdRelationLessThan"
+	case EntSensorThresholdRelationLessOrEqual:
+		return "EntSensorThresholdRelationLessOrEqual"
+	case EntSensorThresholdRelationGreaterThan:
+		return "EntSensorThresholdRelationGreaterThan"
+	case EntSensorThresholdRelationGreaterOrEqual:
+		return "EntSensorThresholdRelationGreaterOrEqual"
+	case EntSensorThresholdRelationEqualTo:
+		return "EntSensorThresholdRelationEqualTo"
+	case EntSensorThresholdRelationNotEqualTo:
+		return "EntSensorThresholdRelationNotEqualTo"
+	default:
+		return fmt.Sprintf("UnknownEntSensorThresholdRelation(%d)", c)
+	}
+}
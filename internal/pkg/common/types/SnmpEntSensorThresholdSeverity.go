@@ -0,0 +1,27 @@
+package types
+
+import "fmt"
+
+type SnmpEntSensorThresholdSeverity uint8
+
+const (
+	EntSensorThresholdSeverityOther    SnmpEntSensorThresholdSeverity = 1
+	EntSensorThresholdSeverityMinor    SnmpEntSensorThresholdSeverity = 10
+	EntSensorThresholdSeverityMajor    SnmpEntSensorThresholdSeverity = 20
+	EntSensorThresholdSeverityCritical SnmpEntSensorThresholdSeverity = 30
+)
+
+func (c SnmpEntSensorThresholdSeverity) String() string {
+	switch c {
+	case EntSensorThresholdSeverityOther:
+		return "EntSensorThresholdSeverityOther"
+	case EntSensorThresholdSeverityMinor:
+		return "EntSensorThresholdSeverityMinor"
+	case EntSensorThresholdSeverityMajor:
+		return "EntSensorThresholdSeverityMajor"
+	case EntSensorThresholdSeverityCritical:
+		return "EntSensorThresholdSeverityCritical"
+	default:
+		return fmt.Sprintf("UnknownEntSensorThresholdSeverity(%d)", c)
+	}
+}
@@ -0,0 +1,33 @@
+package types
+
+import "fmt"
+
+type SnmpHsrpState uint8
+
+const (
+	HsrpStateInitial SnmpHsrpState = iota + 1
+	HsrpStateLearn
+	HsrpStateListen
+	HsrpStateSpeak
+	HsrpStateStandby
+	HsrpStateActive
+)
+
+func (c SnmpHsrpState) String() string {
+	switch c {
+	case HsrpStateInitial:
+		return "HsrpStateInitial"
+	case HsrpStateLearn:
+		return "HsrpStateLearn"
+	case HsrpStateListen:
+		return "HsrpStateListen"
+	case HsrpStateSpeak:
+		return "HsrpStateSpeak"
+	case HsrpStateStandby:
+		return "HsrpStateStandby"
+	case HsrpStateActive:
+		return "HsrpStateActive"
+	default:
+		return fmt.Sprintf("UnknownHsrpState(%d)", c)
+	}
+}
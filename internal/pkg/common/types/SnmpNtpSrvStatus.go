@@ -0,0 +1,33 @@
+package types
+
+import "fmt"
+
+type SnmpNtpSrvStatus uint8
+
+const (
+	NtpSrvStatusUnknown SnmpNtpSrvStatus = iota + 1
+	NtpSrvStatusNotRunning
+	NtpSrvStatusNotSynchronized
+	NtpSrvStatusSyncToLocal
+	NtpSrvStatusSyncToRefclock
+	NtpSrvStatusSyncToRemoteServer
+)
+
+func (c SnmpNtpSrvStatus) String() string {
+	switch c {
+	case NtpSrvStatusUnknown:
+		return "NtpSrvStatusUnknown"
+	case NtpSrvStatusNotRunning:
+		return "NtpSrvStatusNotRunning"
+	case NtpSrvStatusNotSynchronized:
+		return "NtpSrvStatusNotSynchronized"
+	case NtpSrvStatusSyncToLocal:
+		return "NtpSrvStatusSyncToLocal"
+	case NtpSrvStatusSyncToRefclock:
+		return "NtpSrvStatusSyncToRefclock"
+	case NtpSrvStatusSyncToRemoteServer:
+		return "NtpSrvStatusSyncToRemoteServer"
+	default:
+		return fmt.Sprintf("UnknownNtpSrvStatus(%d)", c)
+	}
+}
@@ -0,0 +1,39 @@
+package types
+
+import "fmt"
+
+type SnmpRFMode uint8
+
+const (
+	RFModeNonRedundant SnmpRFMode = iota + 1
+	RFModeStaticLoadShareNonRedundant
+	RFModeDynamicLoadShareNonRedundant
+	RFModeStaticLoadShareRedundant
+	RFModeDynamicLoadShareRedundant
+	RFModeColdStandbyRedundant
+	RFModeWarmStandbyRedundant
+	RFModeHotStandbyRedundant
+)
+
+func (c SnmpRFMode) String() string {
+	switch c {
+	case RFModeNonRedundant:
+		return "RFModeNonRedundant"
+	case RFModeStaticLoadShareNonRedundant:
+		return "RFModeStaticLoadShareNonRedundant"
+	case RFModeDynamicLoadShareNonRedundant:
+		return "RFModeDynamicLoadShareNonRedundant"
+	case RFModeStaticLoadShareRedundant:
+		return "RFModeStaticLoadShareRedundant"
+	case RFModeDynamicLoadShareRedundant:
+		return "RFModeDynamicLoadShareRedundant"
+	case RFModeColdStandbyRedundant:
+		return "RFModeColdStandbyRedundant"
+	case RFModeWarmStandbyRedundant:
+		return "RFModeWarmStandbyRedundant"
+	case RFModeHotStandbyRedundant:
+		return "RFModeHotStandbyRedundant"
+	default:
+		return fmt.Sprintf("UnknownRFMode(%d)", c)
+	}
+}
@@ -0,0 +1,63 @@
+package types
+
+import "fmt"
+
+type SnmpRFState uint8
+
+const (
+	RFStateNotKnown SnmpRFState = iota + 1
+	RFStateDisabled
+	RFStateInitialization
+	RFStateNegotiation
+	RFStateStandbyCold
+	RFStateStandbyColdConfig
+	RFStateStandbyColdFileSys
+	RFStateStandbyColdBulk
+	RFStateStandbyHot
+	RFStateActiveFast
+	RFStateActiveDrain
+	RFStateActivePreconfig
+	RFStateActivePostconfig
+	RFStateActive
+	RFStateActiveExtraload
+	RFStateActiveHandback
+)
+
+func (c SnmpRFState) String() string {
+	switch c {
+	case RFStateNotKnown:
+		return "RFStateNotKnown"
+	case RFStateDisabled:
+		return "RFStateDisabled"
+	case RFStateInitialization:
+		return "RFStateInitialization"
+	case RFStateNegotiation:
+		return "RFStateNegotiation"
+	case RFStateStandbyCold:
+		return "RFStateStandbyCold"
+	case RFStateStandbyColdConfig:
+		return "RFStateStandbyColdConfig"
+	case RFStateStandbyColdFileSys:
+		return "RFStateStandbyColdFileSys"
+	case RFStateStandbyColdBulk:
+		return "RFStateStandbyColdBulk"
+	case RFStateStandbyHot:
+		return "RFStateStandbyHot"
+	case RFStateActiveFast:
+		return "RFStateActiveFast"
+	case RFStateActiveDrain:
+		return "RFStateActiveDrain"
+	case RFStateActivePreconfig:
+		return "RFStateActivePreconfig"
+	case RFStateActivePostconfig:
+		return "RFStateActivePostconfig"
+	case RFStateActive:
+		return "RFStateActive"
+	case RFStateActiveExtraload:
+		return "RFStateActiveExtraload"
+	case RFStateActiveHandback:
+		return "RFStateActiveHandback"
+	default:
+		return fmt.Sprintf("UnknownRFState(%d)", c)
+	}
+}
@@ -0,0 +1,36 @@
+package types
+
+import "fmt"
+
+type SnmpRFSwactReasonType uint8
+
+const (
+	RFSwactReasonUnsupported SnmpRFSwactReasonType = iota + 1
+	RFSwactReasonNone
+	RFSwactReasonNotKnown
+	RFSwactReasonUserInitiated
+	RFSwactReasonUserForced
+	RFSwactReasonActiveUnitFailed
+	RFSwactReasonActiveUnitRemoved
+)
+
+func (c SnmpRFSwactReasonType) String() string {
+	switch c {
+	case RFSwactReasonUnsupported:
+		return "RFSwactReasonUnsupported"
+	case RFSwactReasonNone:
+		return "RFSwactReasonNone"
+	case RFSwactReasonNotKnown:
+		return "RFSwactReasonNotKnown"
+	case RFSwactReasonUserInitiated:
+		return "RFSwactReasonUserInitiated"
+	case RFSwactReasonUserForced:
+		return "RFSwactReasonUserForced"
+	case RFSwactReasonActiveUnitFailed:
+		return "RFSwactReasonActiveUnitFailed"
+	case RFSwactReasonActiveUnitRemoved:
+		return "RFSwactReasonActiveUnitRemoved"
+	default:
+		return fmt.Sprintf("UnknownRFSwactReasonType(%d)", c)
+	}
+}
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Enum is satisfied by the SNMP enum types in this package
+type Enum interface {
+	~uint8
+	String() string
+}
+
+// StateMapValue is a custom flag type for overriding the Icinga state a check gives to enum
+// values, eg. "Removed=critical,VerMismatch=critical,Provisioned=ok". It can be given more
+// than once. Names are matched case insensitively, with or without the type's prefix (so both
+// "Removed" and "SnmpCswSwitchStateRemoved" work, the latter being useful when a check looks
+// at more than one type sharing a value name).
+type StateMapValue struct {
+	Value   map[string]IcingaStatusVal
+	matched map[string]bool
+}
+
+func (s *StateMapValue) String() string {
+	var pairs []string
+	for k, v := range s.Value {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, strings.ToLower(v.String())))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set parses and adds comma separated name=state pairs
+func (s *StateMapValue) Set(value string) error {
+	if s.Value == nil {
+		s.Value = make(map[string]IcingaStatusVal)
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, state, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || name == "" {
+			return fmt.Errorf("invalid state mapping %q, expected name=state", pair)
+		}
+		status, err := ParseIcingaStatus(state)
+		if err != nil {
+			return fmt.Errorf("invalid state mapping %q: %w", pair, err)
+		}
+		s.Value[strings.ToLower(name)] = status
+	}
+	return nil
+}
+
+func (s *StateMapValue) Type() string {
+	return "StateMap"
+}
+
+// Unmatched returns the names given on the command line that didn't match a value of any of
+// the types passed to NewStateMap, so a check can complain about typos
+func (s *StateMapValue) Unmatched() []string {
+	var names []string
+	for k := range s.Value {
+		if !s.matched[k] {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StateMap decides the Icinga state for each value of an enum type, starting from the
+// check's defaults and applying any overrides from a StateMapValue flag
+type StateMap[T Enum] struct {
+	defaults  func(T) IcingaStatusVal
+	overrides map[T]IcingaStatusVal
+}
+
+// NewStateMap builds a StateMap for T, prefix is what's trimmed from String() to get the short
+// name of a value (eg. "SnmpCswSwitchState")
+func NewStateMap[T Enum](flag *StateMapValue, prefix string, defaults func(T) IcingaStatusVal) *StateMap[T] {
+	m := &StateMap[T]{defaults: defaults, overrides: make(map[T]IcingaStatusVal)}
+	if flag.matched == nil {
+		flag.matched = make(map[string]bool)
+	}
+
+	// @Speed: every enum in this package is a uint8, so just try them all
+	for i := 0; i <= 255; i++ {
+		state := T(i)
+		name := state.String()
+		if strings.HasPrefix(name, "Unknown") {
+			continue
+		}
+		for _, key := range []string{strings.ToLower(name), strings.ToLower(strings.TrimPrefix(name, prefix))} {
+			if status, ok := flag.Value[key]; ok {
+				m.overrides[state] = status
+				flag.matched[key] = true
+			}
+		}
+	}
+
+	return m
+}
+
+// Status returns the Icinga state for the given value
+func (m *StateMap[T]) Status(state T) IcingaStatusVal {
+	if status, ok := m.overrides[state]; ok {
+		return status
+	}
+	return m.defaults(state)
+}
@@ -0,0 +1,19 @@
+package types
+
+// Enums generated from the MIBs in internal/pkg/mib/mibs, run "go generate ./..." after changing
+// a MIB or adding a line here. The remaining enums in this package predate the generator and
+// are still written by hand, move them over as their MIBs are added to the registry.
+
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-STACKWISE-MIB.my -object cswSwitchState -type SnmpCswSwitchState
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-STACKWISE-MIB.my -object cswSwitchRole -type SnmpCswSwitchRole
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-STACKWISE-MIB.my -object cswStackPortOperStatus -type SnmpCswStackPortOperStatus
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-STACKWISE-MIB.my -object CswPowerStackMode -type SnmpCswStackPowerMode
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-STACKWISE-MIB.my -object cswStackPowerPortLinkStatus -type SnmpCswStackPowerPortLinkStatus
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-ENTITY-FRU-CONTROL-MIB.my -object PowerOperType -type SnmpPowerOperType -prefix PowerOperType
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/IF-MIB.my -object ifOperStatus -type SnmpIfOperStatus -prefix IfOperStatus -o SnmpIfOperStatusType.go
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-ENTITY-FRU-CONTROL-MIB.my -object ModuleAdminType -type SnmpModuleAdminType -prefix ModuleAdminType
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/CISCO-ENTITY-FRU-CONTROL-MIB.my -object ModuleOperType -type SnmpModuleOperType -prefix ModuleOperType
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/VRRP-MIB.my -object vrrpOperState -type SnmpVrrpOperState -prefix VrrpOperState
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/OSPF-MIB.my -object ospfNbrState -type SnmpOspfNbrState -prefix OspfNbrState
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/POWER-ETHERNET-MIB.my -object pethMainPseOperStatus -type SnmpPethMainPseOperStatus -prefix PethMainPseOperStatus
+//go:generate go run ../../../tools/enumgen -mib ../../mib/mibs/POWER-ETHERNET-MIB.my -object pethPsePortDetectionStatus -type SnmpPethPsePortDetectionStatus -prefix PethPsePortDetectionStatus
@@ -0,0 +1,227 @@
+// Package mib is a small registry of MIB object names, used to write OIDs symbolically in the
+// checks (eg. "CISCO-STACKWISE-MIB::cswSwitchState") and to make OIDs readable in logs.
+//
+// It only understands enough SMI to find "name ... ::= { parent n }" assignments, which is all
+// that's needed to build the OID tree. The MIBs in mibs/ are embedded, and any files in the
+// directories listed in the ICINGAPLUGINS_MIBDIRS environment variable (separated as for PATH)
+// are loaded as well. It's not MIBDIRS so net-snmp's setting doesn't pull in every MIB it has.
+//
+// Nothing is parsed until the first lookup, so checks should keep names as constants and
+// let the SNMP helpers in common resolve them, rather than resolving them at init.
+package mib
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed mibs/*.my
+var embeddedMibs embed.FS
+
+type node struct {
+	module string
+	name   string
+	parent string
+	sub    int
+	oid    string
+}
+
+var (
+	loadOnce sync.Once
+	byName   map[string]*node // keyed by MODULE::name
+	byShort  map[string]*node // keyed by name, the first module loaded wins
+	byOID    map[string]*node
+)
+
+// The roots of the tree, from SNMPv2-SMI and CISCO-SMI, so the trimmed MIBs in mibs/ don't
+// need to carry them
+var wellKnown = []node{
+	{module: "SNMPv2-SMI", name: "iso", oid: "1"},
+	{module: "SNMPv2-SMI", name: "org", oid: "1.3"},
+	{module: "SNMPv2-SMI", name: "dod", oid: "1.3.6"},
+	{module: "SNMPv2-SMI", name: "internet", oid: "1.3.6.1"},
+	{module: "SNMPv2-SMI", name: "mgmt", oid: "1.3.6.1.2"},
+	{module: "SNMPv2-SMI", name: "mib-2", oid: "1.3.6.1.2.1"},
+	{module: "SNMPv2-SMI", name: "transmission", oid: "1.3.6.1.2.1.10"},
+	{module: "SNMPv2-SMI", name: "experimental", oid: "1.3.6.1.3"},
+	{module: "SNMPv2-SMI", name: "private", oid: "1.3.6.1.4"},
+	{module: "SNMPv2-SMI", name: "enterprises", oid: "1.3.6.1.4.1"},
+	{module: "SNMPv2-SMI", name: "snmpV2", oid: "1.3.6.1.6"},
+	{module: "SNMPv2-SMI", name: "snmpModules", oid: "1.3.6.1.6.3"},
+	{module: "CISCO-SMI", name: "cisco", oid: "1.3.6.1.4.1.9"},
+	{module: "CISCO-SMI", name: "ciscoProducts", oid: "1.3.6.1.4.1.9.1"},
+	{module: "CISCO-SMI", name: "local", oid: "1.3.6.1.4.1.9.2"},
+	{module: "CISCO-SMI", name: "ciscoMgmt", oid: "1.3.6.1.4.1.9.9"},
+	{module: "CISCO-SMI", name: "ciscoExperiment", oid: "1.3.6.1.4.1.9.10"},
+}
+
+// mibDirsEnv lists extra directories to load MIBs from
+const mibDirsEnv = "ICINGAPLUGINS_MIBDIRS"
+
+var patternForComment = regexp.MustCompile(`--.*?(--|$)`)
+var patternForModule = regexp.MustCompile(`^\s*([A-Za-z][\w-]*)\s+DEFINITIONS\s*::=\s*BEGIN`)
+var patternForImports = regexp.MustCompile(`(?s)\bIMPORTS\b.*?;`)
+var patternForAssignment = regexp.MustCompile(`(?ms)^\s*([a-z][\w-]*)\s+(?:OBJECT IDENTIFIER|OBJECT-TYPE|MODULE-IDENTITY|OBJECT-IDENTITY|NOTIFICATION-TYPE|OBJECT-GROUP|NOTIFICATION-GROUP|MODULE-COMPLIANCE)\b.*?::=\s*\{\s*([a-zA-Z][\w-]*)\s+(\d+)\s*\}`)
+
+func load() {
+	byName = make(map[string]*node)
+	byShort = make(map[string]*node)
+	byOID = make(map[string]*node)
+
+	var pending []*node
+	for it_index := range wellKnown {
+		add(&wellKnown[it_index])
+	}
+
+	files, _ := fs.Glob(embeddedMibs, "mibs/*.my")
+	for _, it := range files {
+		data, err := embeddedMibs.ReadFile(it)
+		if err != nil {
+			slog.Warn("Unable to read embedded MIB", "file", it, "error", err)
+			continue
+		}
+		nodes, err := parse(string(data))
+		if err != nil {
+			slog.Warn("Unable to parse embedded MIB", "file", it, "error", err)
+			continue
+		}
+		pending = append(pending, nodes...)
+	}
+
+	for _, dir := range filepath.SplitList(os.Getenv(mibDirsEnv)) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			slog.Warn("Unable to read MIB directory", "dir", dir, "error", err)
+			continue
+		}
+		for _, it := range entries {
+			if it.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, it.Name())
+			data, err := os.ReadFile(path)
+			if err != nil {
+				slog.Warn("Unable to read MIB", "file", path, "error", err)
+				continue
+			}
+			nodes, err := parse(string(data))
+			if err != nil {
+				slog.Debug("Skipping file in MIB directory", "file", path, "error", err)
+				continue
+			}
+			pending = append(pending, nodes...)
+		}
+	}
+
+	// parents can be defined after their children, or in another module, so keep going until
+	// nothing else resolves
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+		var unresolved []*node
+		for _, it := range pending {
+			parent, ok := byName[it.module+"::"+it.parent]
+			if !ok {
+				parent, ok = byShort[it.parent]
+			}
+			if !ok {
+				unresolved = append(unresolved, it)
+				continue
+			}
+			it.oid = parent.oid + "." + strconv.Itoa(it.sub)
+			add(it)
+			progress = true
+		}
+		pending = unresolved
+	}
+	for _, it := range pending {
+		slog.Debug("Unable to resolve MIB object, its parent isn't loaded", "object", it.module+"::"+it.name, "parent", it.parent)
+	}
+}
+
+func add(n *node) {
+	byName[n.module+"::"+n.name] = n
+	if _, ok := byShort[n.name]; !ok {
+		byShort[n.name] = n
+	}
+	if _, ok := byOID[n.oid]; !ok {
+		byOID[n.oid] = n
+	}
+}
+
+// parse returns the OID assignments in a MIB module, unresolved
+func parse(mib string) ([]*node, error) {
+	lines := strings.Split(mib, "\n")
+	for it_index, it := range lines {
+		lines[it_index] = patternForComment.ReplaceAllString(it, "")
+	}
+	mib = strings.Join(lines, "\n")
+
+	module := patternForModule.FindStringSubmatch(mib)
+	if module == nil {
+		return nil, fmt.Errorf("not a MIB module, no DEFINITIONS ::= BEGIN")
+	}
+	mib = patternForImports.ReplaceAllString(mib, "")
+
+	var nodes []*node
+	for _, it := range patternForAssignment.FindAllStringSubmatch(mib, -1) {
+		sub, err := strconv.Atoi(it[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid sub-identifier for %s: %w", it[1], err)
+		}
+		nodes = append(nodes, &node{module: module[1], name: it[1], parent: it[2], sub: sub})
+	}
+	return nodes, nil
+}
+
+// OID resolves a name like "CISCO-STACKWISE-MIB::cswSwitchState" to its numeric OID. The module
+// can be left off, and an instance can be given after the name, eg. "SNMPv2-MIB::sysUpTime.0".
+func OID(name string) (string, error) {
+	loadOnce.Do(load)
+
+	object, instance, _ := strings.Cut(name, ".")
+	var n *node
+	var ok bool
+	if strings.Contains(object, "::") {
+		n, ok = byName[object]
+	} else {
+		n, ok = byShort[object]
+	}
+	if !ok {
+		return "", fmt.Errorf("unknown MIB object %s", object)
+	}
+
+	if instance == "" {
+		return n.oid, nil
+	}
+	return n.oid + "." + instance, nil
+}
+
+// Name translates a numeric OID back to MODULE::name, followed by whatever is left of the OID
+// (usually the index). OIDs outside of the loaded MIBs are returned unchanged.
+func Name(oid string) string {
+	loadOnce.Do(load)
+
+	trimmed := strings.TrimPrefix(oid, ".")
+	for prefix := trimmed; prefix != ""; {
+		if n, ok := byOID[prefix]; ok {
+			return n.module + "::" + n.name + trimmed[len(prefix):]
+		}
+		i := strings.LastIndex(prefix, ".")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return oid
+}
@@ -0,0 +1,246 @@
+// enumgen generates an enum type for the types package from an INTEGER enumeration in a MIB,
+// either a TEXTUAL-CONVENTION or an OBJECT-TYPE with the enumeration inline. It's run with
+// go generate, see internal/pkg/common/types/generate.go.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type enumValue struct {
+	Label     string // as written in the MIB, eg. sdmMismatch
+	ConstName string // eg. SnmpCswSwitchStateSdmMismatch
+	Value     int
+}
+
+var patternForComment = regexp.MustCompile(`--.*?(--|$)`)
+var patternForEnumValue = regexp.MustCompile(`([a-zA-Z][\w-]*)\s*\(\s*(-?\d+)\s*\)`)
+var patternForSyntax = regexp.MustCompile(`SYNTAX\s+(INTEGER\s*\{([^}]*)\}|[A-Z][\w-]*)`)
+
+func main() {
+	mibFile := flag.String("mib", "", "MIB file to read (required)")
+	object := flag.String("object", "", "Name of the TEXTUAL-CONVENTION or OBJECT-TYPE with the enumeration (required)")
+	typeName := flag.String("type", "", "Name of the generated type (required)")
+	prefix := flag.String("prefix", "", "Prefix of the generated constants (default the type name)")
+	unknownPrefix := flag.String("unknown", "", "What String() returns for values not in the MIB, followed by the value in brackets (default Unknown and the type name without Snmp)")
+	output := flag.String("o", "", "File to write (default <type>.go)")
+	flag.Parse()
+
+	if *mibFile == "" || *object == "" || *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *prefix == "" {
+		*prefix = *typeName
+	}
+	if *unknownPrefix == "" {
+		*unknownPrefix = "Unknown" + strings.TrimPrefix(*typeName, "Snmp")
+	}
+	if *output == "" {
+		*output = *typeName + ".go"
+	}
+
+	data, err := os.ReadFile(*mibFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mib := stripComments(string(data))
+
+	module := strings.Fields(mib)[0]
+	values, err := findEnumeration(mib, *object, 0)
+	if err != nil {
+		log.Fatalf("%s: %v", *mibFile, err)
+	}
+	for it_index := range values {
+		values[it_index].ConstName = *prefix + constSuffix(values[it_index].Label)
+	}
+
+	var buf bytes.Buffer
+	err = typeTemplate.Execute(&buf, map[string]any{
+		"Source":        module + "::" + *object,
+		"Type":          *typeName,
+		"Prefix":        *prefix,
+		"LowerPrefix":   strings.ToLower(*prefix),
+		"Unknown":       *unknownPrefix,
+		"Values":        values,
+		"LowerTypeName": strings.ToLower((*typeName)[:1]) + (*typeName)[1:],
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatalf("generated code doesn't compile: %v\n%s", err, buf.String())
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// stripComments removes ASN.1 comments, which run from "--" to the next "--" or the end of the line
+func stripComments(mib string) string {
+	lines := strings.Split(mib, "\n")
+	for it_index, it := range lines {
+		lines[it_index] = patternForComment.ReplaceAllString(it, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// findEnumeration returns the values of the INTEGER enumeration of the named definition,
+// following SYNTAX through textual conventions defined in the same MIB
+func findEnumeration(mib string, name string, depth int) ([]enumValue, error) {
+	if depth > 5 {
+		return nil, fmt.Errorf("too many levels of textual conventions looking for %s", name)
+	}
+
+	definition := regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(name) + `\s+(OBJECT-TYPE|::=\s*TEXTUAL-CONVENTION)`)
+	location := definition.FindStringIndex(mib)
+	if location == nil {
+		return nil, fmt.Errorf("no OBJECT-TYPE or TEXTUAL-CONVENTION named %s", name)
+	}
+
+	// the definition ends at its "::=", or the next one for a textual convention
+	body := mib[location[1]:]
+	if end := strings.Index(body, "::="); end >= 0 {
+		body = body[:end]
+	}
+
+	syntax := patternForSyntax.FindStringSubmatch(body)
+	if syntax == nil {
+		return nil, fmt.Errorf("%s has no SYNTAX", name)
+	}
+	if !strings.HasPrefix(syntax[1], "INTEGER") {
+		return findEnumeration(mib, syntax[1], depth+1)
+	}
+
+	var values []enumValue
+	for _, it := range patternForEnumValue.FindAllStringSubmatch(syntax[2], -1) {
+		v, err := strconv.Atoi(it[2])
+		if err != nil {
+			return nil, fmt.Errorf("%s has an invalid value for %s: %w", name, it[1], err)
+		}
+		if v < 0 || v > 255 {
+			return nil, fmt.Errorf("%s has value %d for %s, which doesn't fit in a uint8", name, v, it[1])
+		}
+		values = append(values, enumValue{Label: it[1], Value: v})
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s has an empty enumeration", name)
+	}
+	return values, nil
+}
+
+// constSuffix turns a MIB label into the end of a Go constant name, eg. sdmMismatch becomes
+// SdmMismatch and power-sharing becomes PowerSharing
+func constSuffix(label string) string {
+	var b strings.Builder
+	upper := true
+	for _, it := range label {
+		if it == '-' || it == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(it)))
+			upper = false
+		} else {
+			b.WriteRune(it)
+		}
+	}
+	return b.String()
+}
+
+var typeTemplate = template.Must(template.New("type").Parse(`// Code generated by enumgen from {{.Source}}; DO NOT EDIT.
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type {{.Type}} uint8
+
+const (
+{{- range .Values}}
+	{{.ConstName}} {{$.Type}} = {{.Value}}
+{{- end}}
+)
+
+// {{.Type}}Values lists the values defined by the MIB
+var {{.Type}}Values = []{{.Type}}{
+{{- range .Values}}
+	{{.ConstName}},
+{{- end}}
+}
+
+var {{.LowerTypeName}}Labels = map[{{.Type}}]string{
+{{- range .Values}}
+	{{.ConstName}}: "{{.Label}}",
+{{- end}}
+}
+
+func (c {{.Type}}) String() string {
+	switch c {
+{{- range .Values}}
+	case {{.ConstName}}:
+		return "{{.ConstName}}"
+{{- end}}
+	default:
+		return fmt.Sprintf("{{.Unknown}}(%d)", c)
+	}
+}
+
+// Parse{{.Type}} returns the value with the given name, matched case insensitively against
+// the MIB label (eg. "{{(index .Values 0).Label}}") or the constant name with or without the
+// "{{.Prefix}}" prefix
+func Parse{{.Type}}(name string) ({{.Type}}, error) {
+	lower := strings.ToLower(name)
+	for _, it := range {{.Type}}Values {
+		constName := strings.ToLower(it.String())
+		if lower == strings.ToLower({{.LowerTypeName}}Labels[it]) || lower == constName || lower == strings.TrimPrefix(constName, "{{.LowerPrefix}}") {
+			return it, nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not a valid {{.Type}}", name)
+}
+
+// MarshalJSON encodes the value as its MIB label, or its number when the MIB doesn't define it
+func (c {{.Type}}) MarshalJSON() ([]byte, error) {
+	if label, ok := {{.LowerTypeName}}Labels[c]; ok {
+		return json.Marshal(label)
+	}
+	return []byte(strconv.Itoa(int(c))), nil
+}
+
+// UnmarshalJSON accepts anything Parse{{.Type}} does, or a number
+func (c *{{.Type}}) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var v uint8
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("{{.Type}} must be a string or a number: %w", err)
+		}
+		*c = {{.Type}}(v)
+		return nil
+	}
+	v, err := Parse{{.Type}}(name)
+	if err != nil {
+		return err
+	}
+	*c = v
+	return nil
+}
+`))
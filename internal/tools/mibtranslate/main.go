@@ -0,0 +1,54 @@
+// mibtranslate replaces numeric OIDs with MODULE::name in SNMP dumps (eg. snmpwalk -On output,
+// or a recording of a device), using the same MIB registry as the checks. It reads the files
+// given as arguments, or stdin, and writes to stdout:
+//
+//	go run ./internal/tools/mibtranslate device.snmpwalk
+//
+// OIDs outside of the loaded MIBs are left as they are. Extra MIBs are picked up from
+// ICINGAPLUGINS_MIBDIRS, as for the checks.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/jamiereid/go-icingaplugins/internal/pkg/mib"
+)
+
+var patternForOID = regexp.MustCompile(`\.?\b1\.3\.6\.1(\.\d+)+`)
+
+func main() {
+	if len(os.Args) < 2 {
+		if err := translate(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	for _, it := range os.Args[1:] {
+		f, err := os.Open(it)
+		if err != nil {
+			log.Fatal(err)
+		}
+		err = translate(f, os.Stdout)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", it, err)
+		}
+	}
+}
+
+func translate(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024) // string values can be long
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(w, patternForOID.ReplaceAllStringFunc(scanner.Text(), mib.Name)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}